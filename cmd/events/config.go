@@ -0,0 +1,18 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/config"
+)
+
+const configUsage = "usage: events [flags] config print"
+
+// runConfig implements the config subcommand
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+	return cfg.Print(os.Stdout)
+}
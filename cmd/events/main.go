@@ -1,51 +1,314 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/config"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/auth"
 	httpHandler "github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/http"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/metrics"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/ratelimit"
 	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
 	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service/repository"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/tracing"
 	"github.com/devfullcycle/imersao18/golang/internal/events/usecase"
+	"github.com/devfullcycle/imersao18/golang/internal/logging"
 )
 
 func main() {
-	db, err := sql.Open("mysql", "test_user:password@tcp(127.0.0.1:3306)/test_database)")
+	cfg, args, err := config.Load(flag.CommandLine, os.Args[1:], os.LookupEnv)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if len(args) > 0 && args[0] == "config" {
+		if err := runConfig(cfg, args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
 	}
-	defer db.Close()
 
-	eventRepo, err := repository.NewMysqlEventRepository(db)
+	if len(args) > 0 && args[0] == "migrate" && cfg.Database.Store == config.StoreMemory {
+		fmt.Fprintln(os.Stderr, "migrate: the memory store has no schema, choose mysql, postgres or sqlite with -database.store")
+		os.Exit(2)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, cfg.Log.SlogLevel())
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
+	slog.SetDefault(logger)
 
-	partnerBaseURLs := map[int]string{
-		1: "http://localhost:3333",
-		3: "http://localhost:3334",
+	// ctx is canceled by SIGINT or SIGTERM, which starts the graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.TracingConfig())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
+	defer shutdownTracing(context.Background())
+
+	var db *sql.DB
+	var eventRepo domain.EventRepository
+	var idempotencyRepo domain.IdempotencyRepository
+	if cfg.Database.Store == config.StoreMemory {
+		eventRepo = repository.NewMemoryEventRepository()
+		idempotencyRepo = repository.NewMemoryIdempotencyRepository()
+	} else {
+		db, err = openDatabase(ctx, cfg.Database)
+		if err != nil {
+			panic(err)
+		}
+		defer db.Close()
+
+		if len(args) > 0 && args[0] == "migrate" {
+			if err := runMigrate(db, cfg.Database.Store, args[1:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		eventRepo, idempotencyRepo, err = newSqlRepositories(cfg.Database.Store, db)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	appMetrics := metrics.NewMetrics()
+	eventRepo = tracing.InstrumentEventRepository(appMetrics.InstrumentEventRepository(eventRepo))
 
-	partnerFactory := service.NewPartnerFactory(partnerBaseURLs)
+	partnerDefinitions := cfg.Partners.Definitions
+	partnerFactory, err := service.NewPartnerFactory(service.DefaultPartnerRegistry(), partnerDefinitions, cfg.Partners.Transport.TransportConfig())
+	if err != nil {
+		panic(err)
+	}
+	partnerFactory = tracing.InstrumentPartnerFactory(appMetrics.InstrumentPartnerFactory(partnerFactory))
 
 	listEventsUseCase := usecase.NewListEventsUseCase(eventRepo)
 	getEventsUseCase := usecase.NewGetEventUseCase(eventRepo)
 	listSpotsUseCase := usecase.NewListSpotsUseCase(eventRepo)
-	buyTicketUseCase := usecase.NewBuyTicketsUseCase(eventRepo, partnerFactory)
+	buyTicketUseCase := appMetrics.InstrumentBuyTickets(usecase.NewBuyTicketsUseCase(
+		eventRepo,
+		partnerFactory,
+		usecase.WithMaxSpotsPerOrder(cfg.Checkout.MaxSpotsPerOrder),
+		usecase.WithReservationPolicy(usecase.ReservationPolicy(cfg.Checkout.ReservationPolicy)),
+	))
+	createEventUseCase := usecase.NewCreateEventUseCase(eventRepo, domain.NewSpotService(), partnerFactory)
+	updateEventUseCase := usecase.NewUpdateEventUseCase(eventRepo, partnerFactory)
+	deleteEventUseCase := usecase.NewDeleteEventUseCase(eventRepo)
+	holdOptions := []usecase.HoldSpotsOption{usecase.WithHoldMaxSpots(cfg.Checkout.MaxSpotsPerOrder)}
+
+	// Events in waiting-room mode only sell to buyers admitted from their queue
+	waitingRooms := repository.NewMemoryWaitingRoomRepository()
+	if cfg.WaitingRoom.Enabled {
+		buyTicketUseCase = usecase.NewWaitingRoomBuyTickets(buyTicketUseCase, waitingRooms, eventRepo)
+		holdOptions = append(holdOptions, usecase.WithHoldWaitingRooms(waitingRooms))
+	}
+	holdSpotsUseCase := usecase.NewHoldSpotsUseCase(eventRepo, cfg.Checkout.HoldDuration, holdOptions...)
+	waitingRoomHandler := httpHandler.NewWaitingRoomHandler(
+		usecase.NewGetWaitingRoomUseCase(waitingRooms),
+		usecase.NewControlWaitingRoomUseCase(eventRepo, waitingRooms, cfg.WaitingRoom.AdmissionsPerMinute, cfg.WaitingRoom.AdmissionWindow),
+		usecase.NewJoinWaitingRoomUseCase(waitingRooms),
+		usecase.NewGetQueueTokenUseCase(waitingRooms),
+	)
+
+	// The in-memory store starts empty, give the front end something to show
+	if cfg.Database.Store == config.StoreMemory && cfg.Features.SeedDemoEvents {
+		if err := seedDemoEvents(ctx, createEventUseCase); err != nil {
+			panic(err)
+		}
+	}
+	releaseExpiredHoldsUseCase := usecase.NewReleaseExpiredHoldsUseCase(eventRepo)
+
+	// Refuse to start when events would be sold through a partner we cannot reach
+	partnerIDs, err := eventRepo.ListPartnerIDs(ctx)
+	if err != nil {
+		panic(err)
+	}
+	if err := service.CheckPartners(partnerFactory, partnerIDs); err != nil {
+		panic(err)
+	}
 
 	eventsHandler := httpHandler.NewEventHandler(
 		listEventsUseCase,
 		listSpotsUseCase,
 		getEventsUseCase,
 		buyTicketUseCase,
+		createEventUseCase,
+		updateEventUseCase,
+		deleteEventUseCase,
+		holdSpotsUseCase,
 	)
 
+	// Give expired holds back to the pool of available spots and forget old
+	// idempotency keys
+	go func() {
+		ticker := time.NewTicker(cfg.Checkout.CleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if _, err := releaseExpiredHoldsUseCase.Execute(ctx); err != nil {
+				slog.Error("release expired holds", "error", err)
+			}
+			if _, err := idempotencyRepo.DeleteBefore(ctx, time.Now().Add(-cfg.Checkout.IdempotencyKeyTTL)); err != nil {
+				slog.Error("delete expired idempotency keys", "error", err)
+			}
+		}
+	}()
+
+	health := httpHandler.NewHealthHandler(httpHandler.DefaultHealthCheckTimeout)
+	if db != nil {
+		health.AddCheck("database", db.PingContext)
+	}
+	if cfg.Features.ReadyProbePartners {
+		for _, definition := range partnerDefinitions {
+			partner, err := partnerFactory.CreatePartner(definition.ID)
+			if err != nil {
+				panic(err)
+			}
+			health.AddCheck("partner_"+definition.Name, func(ctx context.Context) error {
+				return service.CheckPartnerHealth(ctx, partner)
+			})
+		}
+	}
+
+	var authenticator auth.Authenticator
+	if cfg.Auth.Enabled {
+		if authenticator, err = cfg.Auth.Authenticator(); err != nil {
+			panic(err)
+		}
+	} else {
+		slog.Warn("AUTHENTICATION IS DISABLED: every route is open to anyone, never run like this in production")
+	}
+
+	limiter := ratelimit.NewMemoryLimiter()
+	clientKey := httpHandler.ClientKey(cfg.RateLimit.TrustForwardedFor)
+
+	// handle registers a route open to callers with any of roles, or to
+	// everyone when no role is given or authentication is disabled. Rate
+	// limits run after authentication to key on the caller identity.
 	r := http.NewServeMux()
-	r.HandleFunc("/events", eventsHandler.ListEvents)
-	r.HandleFunc("/events/{eventID}", eventsHandler.GetEvents)
-	r.HandleFunc("/events/{eventID}", eventsHandler.GetEvents)
-	r.HandleFunc("/events/{eventID}/spots", eventsHandler.ListSpots)
-	r.HandleFunc("POST /checkout", eventsHandler.BuyTickets)
-	http.ListenAndServe(":8080", r)
+	handle := func(pattern string, handler http.HandlerFunc, roles ...auth.Role) {
+		var h http.Handler = handler
+		if cfg.RateLimit.Enabled {
+			h = httpHandler.RateLimitMiddleware(limiter, pattern, cfg.RateLimit.RouteLimit(pattern), clientKey)(h)
+		}
+		if authenticator != nil && len(roles) > 0 {
+			h = httpHandler.AuthMiddleware(authenticator, roles...)(h)
+		}
+		r.Handle(pattern, tracing.InstrumentHandler(pattern, appMetrics.InstrumentHandler(pattern, h)))
+	}
+	handle("GET /events", eventsHandler.ListEvents)
+	handle("POST /events", eventsHandler.CreateEvent, auth.RoleOrganizer, auth.RoleAdmin)
+	handle("GET /events/{eventID}", eventsHandler.GetEvents)
+	handle("PUT /events/{eventID}", eventsHandler.UpdateEvent, auth.RoleOrganizer, auth.RoleAdmin)
+	handle("DELETE /events/{eventID}", eventsHandler.DeleteEvent, auth.RoleOrganizer, auth.RoleAdmin)
+	handle("GET /events/{eventID}/spots", eventsHandler.ListSpots)
+	handle("POST /events/{eventID}/holds", eventsHandler.HoldSpots, auth.RoleBuyer, auth.RolePartner, auth.RoleAdmin)
+	checkout := httpHandler.IdempotencyMiddleware(idempotencyRepo, clientKey)(http.HandlerFunc(eventsHandler.BuyTickets))
+	if cfg.RateLimit.Enabled {
+		checkout = httpHandler.EventRateLimitMiddleware(limiter, cfg.RateLimit.CheckoutPerEvent.Limit(), clientKey)(checkout)
+	}
+	handle("POST /checkout", checkout.ServeHTTP, auth.RoleBuyer, auth.RolePartner, auth.RoleAdmin)
+	if cfg.WaitingRoom.Enabled {
+		handle("GET /events/{eventID}/waiting-room", waitingRoomHandler.GetWaitingRoom)
+		handle("POST /events/{eventID}/waiting-room/{action}", waitingRoomHandler.ControlWaitingRoom, auth.RoleOrganizer, auth.RoleAdmin)
+		handle("POST /events/{eventID}/waiting-room/tokens", waitingRoomHandler.JoinWaitingRoom, auth.RoleBuyer, auth.RolePartner, auth.RoleAdmin)
+		handle("GET /events/{eventID}/waiting-room/tokens/{token}", waitingRoomHandler.GetQueueToken, auth.RoleBuyer, auth.RolePartner, auth.RoleAdmin)
+	}
+	if cfg.Features.Metrics {
+		r.Handle("GET /metrics", appMetrics.Handler())
+	}
+	r.HandleFunc("GET /healthz", health.Liveness)
+	r.HandleFunc("GET /readyz", health.Readiness)
+
+	server := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+	server.Handler = httpHandler.RequestIDMiddleware(httpHandler.LoggingMiddleware(r))
+	server.ErrorLog = slog.NewLogLogger(logger.Handler(), slog.LevelError)
+
+	slog.Info("listening", "addr", server.Addr, "store", cfg.Database.Store)
+	if err := serve(ctx, server, health, cfg.HTTP.DrainDelay, cfg.HTTP.ShutdownTimeout); err != nil {
+		slog.Error("server stopped", "error", err)
+		shutdownTracing(context.Background())
+		os.Exit(1)
+	}
+	slog.Info("server stopped")
+}
+
+// openDatabase opens the pool and checks the database answers, so a wrong DSN
+// fails the startup instead of the first request
+func openDatabase(ctx context.Context, cfg config.DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open(cfg.DriverName(), cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to %s: %w", cfg.Store, err)
+	}
+	return db, nil
+}
+
+// newSqlRepositories picks the repository implementations for a store
+func newSqlRepositories(store string, db *sql.DB) (domain.EventRepository, domain.IdempotencyRepository, error) {
+	var eventRepo domain.EventRepository
+	var idempotencyRepo domain.IdempotencyRepository
+	var err error
+
+	switch store {
+	case "mysql":
+		if eventRepo, err = repository.NewMysqlEventRepository(db); err == nil {
+			idempotencyRepo, err = repository.NewMysqlIdempotencyRepository(db)
+		}
+	case "postgres":
+		if eventRepo, err = repository.NewPostgresEventRepository(db); err == nil {
+			idempotencyRepo, err = repository.NewPostgresIdempotencyRepository(db)
+		}
+	case "sqlite":
+		if eventRepo, err = repository.NewSqliteEventRepository(db); err == nil {
+			idempotencyRepo, err = repository.NewSqliteIdempotencyRepository(db)
+		}
+	default:
+		err = fmt.Errorf("unknown store: %s", store)
+	}
+
+	return eventRepo, idempotencyRepo, err
 }
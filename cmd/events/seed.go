@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/usecase"
+)
+
+// seedDemoEvents creates a few upcoming events with generated spots, dated
+// relative to now so they always pass validation
+func seedDemoEvents(ctx context.Context, createEventUseCase *usecase.CreateEventUseCase) error {
+	now := time.Now()
+	events := []usecase.CreateEventInputDTO{
+		{
+			Name:         "Rock in Full Cycle",
+			Location:     "São Paulo, SP",
+			Organization: "Full Cycle",
+			Rating:       "L16",
+			Date:         now.AddDate(0, 1, 0).Format("2006-01-02 15:04:05"),
+			ImageURL:     "https://images.unsplash.com/photo-1540039155733-5bb30b53aa14",
+			Capacity:     100,
+			Price:        300,
+			PartnerID:    1,
+			Spots:        50,
+		},
+		{
+			Name:         "Festival de Jazz",
+			Location:     "Rio de Janeiro, RJ",
+			Organization: "Jazz Brasil",
+			Rating:       "L",
+			Date:         now.AddDate(0, 2, 0).Format("2006-01-02 15:04:05"),
+			ImageURL:     "https://images.unsplash.com/photo-1511192336575-5a79af67a629",
+			Capacity:     60,
+			Price:        150,
+			PartnerID:    2,
+			Spots:        30,
+		},
+		{
+			Name:         "Stand-up Night",
+			Location:     "Belo Horizonte, MG",
+			Organization: "Comedy Club",
+			Rating:       "L14",
+			Date:         now.AddDate(0, 0, 14).Format("2006-01-02 15:04:05"),
+			ImageURL:     "https://images.unsplash.com/photo-1527224857830-43a7acc85260",
+			Capacity:     40,
+			Price:        80,
+			PartnerID:    1,
+			Spots:        20,
+		},
+	}
+
+	for _, event := range events {
+		if _, err := createEventUseCase.Execute(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	httpHandler "github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/http"
+)
+
+// serve runs server until ctx is canceled. It then fails readiness, waits
+// drainDelay for load balancers to notice, stops accepting connections and
+// waits up to shutdownTimeout for in-flight requests, checkouts included.
+func serve(ctx context.Context, server *http.Server, health *httpHandler.HealthHandler, drainDelay, shutdownTimeout time.Duration) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down", "drain_delay", drainDelay.String(), "timeout", shutdownTimeout.String())
+	health.Drain()
+	time.Sleep(drainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
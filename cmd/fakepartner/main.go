@@ -0,0 +1,67 @@
+// Command fakepartner serves fake Partner1 and Partner2 APIs on the ports
+// configs/partners.yaml points at, so the events service runs without the real
+// partners. Failures can be scripted with flags or at runtime through the
+// /_fake endpoints.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/devfullcycle/imersao18/golang/internal/fakepartner"
+)
+
+func main() {
+	partner1Addr := flag.String("partner1-addr", ":3333", "address of the fake Partner1, empty to disable it")
+	partner2Addr := flag.String("partner2-addr", ":3334", "address of the fake Partner2, empty to disable it")
+	partner1Token := flag.String("partner1-token", "", "bearer token Partner1 requires, empty accepts any request")
+	partner2Token := flag.String("partner2-token", "", "bearer token Partner2 requires, empty accepts any request")
+	sold := flag.String("sold", "", "comma separated EVENT_ID:SPOT pairs sold before start, e.g. 1:A1,1:A2")
+	var failures fakepartner.Failures
+	flag.DurationVar(&failures.Latency, "latency", 0, "delay of every reservation answer")
+	flag.IntVar(&failures.StatusCode, "fail-status", 0, "answer reservations with this HTTP status, e.g. 503")
+	flag.BoolVar(&failures.MalformedJSON, "malformed-json", false, "answer reservations with a broken JSON body")
+	flag.IntVar(&failures.Times, "fail-times", 0, "how many reservations fail, 0 for all of them")
+	flag.Parse()
+
+	type listener struct {
+		name string
+		addr string
+		*fakepartner.Server
+	}
+	listeners := []listener{
+		{"partner1", *partner1Addr, fakepartner.NewServer(fakepartner.Partner1Protocol, *partner1Token)},
+		{"partner2", *partner2Addr, fakepartner.NewServer(fakepartner.Partner2Protocol, *partner2Token)},
+	}
+
+	errs := make(chan error, len(listeners))
+	started := 0
+	for _, l := range listeners {
+		if l.addr == "" {
+			continue
+		}
+
+		l.SetFailures(failures)
+		for _, pair := range strings.Split(*sold, ",") {
+			eventID, spot, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if ok {
+				l.MarkSold(eventID, spot)
+			}
+		}
+
+		started++
+		go func() {
+			log.Printf("fake %s listening on %s", l.name, l.addr)
+			errs <- fmt.Errorf("%s: %w", l.name, http.ListenAndServe(l.addr, l.Server))
+		}()
+	}
+
+	if started == 0 {
+		log.Fatal(errors.New("every partner is disabled"))
+	}
+	log.Fatal(<-errs)
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
+	"gopkg.in/yaml.v3"
+)
+
+const envPrefix = "EVENTS_"
+
+// flagAliases keeps flags that were renamed working, by setting path
+var flagAliases = map[string]string{
+	"database.store": "store",
+}
+
+// Load builds the configuration from the defaults, the YAML file named by
+// -config or EVENTS_CONFIG, the EVENTS_* environment variables and the flags
+// in args, then validates it. It registers its flags on fs and returns the
+// arguments left after them.
+func Load(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (*Config, []string, error) {
+	config := Default()
+	settings := settingsOf(reflect.ValueOf(&config).Elem(), "")
+
+	file := fs.String("config", "", "YAML configuration file ("+envPrefix+"CONFIG)")
+	flagValues := make(map[string]reflect.Value)
+	for _, s := range settings {
+		usage := fmt.Sprintf("%s (%s, default %v)", s.usage, s.envName(), s.value.Interface())
+		set := func(raw string) error {
+			value, err := parse(s.value.Type(), raw)
+			if err != nil {
+				return err
+			}
+			flagValues[s.path] = value
+			return nil
+		}
+		if s.value.Kind() == reflect.Bool {
+			fs.BoolFunc(s.flagName(), usage, set)
+		} else {
+			fs.Func(s.flagName(), usage, set)
+		}
+		if alias, ok := flagAliases[s.path]; ok {
+			fs.Func(alias, "deprecated, use -"+s.flagName(), set)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	path := *file
+	if path == "" {
+		path, _ = lookupEnv(envPrefix + "CONFIG")
+	}
+	if path != "" {
+		if err := loadFile(&config, path); err != nil {
+			return nil, nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+		// Paths in the file are relative to it, not to where the server runs
+		for _, file := range []*string{&config.Partners.File, &config.Auth.JWKSFile} {
+			if *file != "" && !filepath.IsAbs(*file) {
+				*file = filepath.Join(filepath.Dir(path), *file)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		raw, ok := lookupEnv(s.envName())
+		if !ok {
+			continue
+		}
+		value, err := parse(s.value.Type(), raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", s.envName(), err)
+		}
+		s.value.Set(value)
+	}
+
+	for _, s := range settings {
+		if value, ok := flagValues[s.path]; ok {
+			s.value.Set(value)
+		}
+	}
+
+	if err := config.resolve(); err != nil {
+		return nil, nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return &config, fs.Args(), nil
+}
+
+// resolve fills in the settings derived from others
+func (c *Config) resolve() error {
+	if c.Database.DSN == "" {
+		c.Database.DSN = defaultDSNs[c.Database.Store]
+	}
+
+	if len(c.Partners.Definitions) == 0 && c.Partners.File != "" {
+		definitions, err := service.LoadPartnerDefinitions(c.Partners.File)
+		if err != nil {
+			return fmt.Errorf("partners.file %s: %w", c.Partners.File, err)
+		}
+		c.Partners.Definitions = definitions
+	} else if len(c.Partners.Definitions) == 0 {
+		c.Partners.Definitions = defaultPartners
+	}
+
+	return nil
+}
+
+// loadFile decodes a YAML file over config, keys it does not know are errors
+func loadFile(config *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// setting is a scalar field of Config, addressed by its YAML path
+type setting struct {
+	path   string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// settingsOf lists the scalar fields of a struct. Lists and maps, like the
+// partner definitions, can only be set in the file.
+func settingsOf(v reflect.Value, prefix string) []setting {
+	var settings []setting
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			settings = append(settings, settingsOf(v.Field(i), prefix+name+".")...)
+		case reflect.String, reflect.Int, reflect.Int64, reflect.Bool:
+			settings = append(settings, setting{
+				path:   prefix + name,
+				usage:  field.Tag.Get("usage"),
+				secret: field.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	return settings
+}
+
+func (s setting) envName() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(s.path, ".", "_"))
+}
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(s.path, "_", "-")
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// parse converts the text of an environment variable or flag to a field value
+func parse(t reflect.Type, raw string) (reflect.Value, error) {
+	value := reflect.New(t).Elem()
+	switch {
+	case t == durationType:
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return value, err
+		}
+		value.SetInt(int64(duration))
+	case t.Kind() == reflect.String:
+		value.SetString(raw)
+	case t.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return value, err
+		}
+		value.SetBool(b)
+	case t.Kind() == reflect.Int || t.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return value, err
+		}
+		value.SetInt(n)
+	default:
+		return value, fmt.Errorf("unsupported setting type %s", t)
+	}
+	return value, nil
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
+	"gopkg.in/yaml.v3"
+)
+
+const mask = "****"
+
+// Print writes the configuration as YAML with its secrets masked
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Masked()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// Masked returns a copy of the configuration that is safe to show: secret
+// settings, the DSN password, partner credentials and API keys are replaced by
+// ****. Values that reference an environment variable are kept, they hold no
+// secret themselves.
+func (c Config) Masked() Config {
+	for _, s := range settingsOf(reflect.ValueOf(&c).Elem(), "") {
+		if s.secret && s.value.String() != "" {
+			s.value.SetString(mask)
+		}
+	}
+	c.Database.DSN = MaskDSN(c.Database.DSN)
+
+	definitions := make([]service.PartnerDefinition, len(c.Partners.Definitions))
+	for i, definition := range c.Partners.Definitions {
+		credentials := make(map[string]string, len(definition.Credentials))
+		for name, value := range definition.Credentials {
+			credentials[name] = maskLiteral(value)
+		}
+		definition.Credentials = credentials
+		definitions[i] = definition
+	}
+	c.Partners.Definitions = definitions
+
+	apiKeys := make([]APIKeyConfig, len(c.Auth.APIKeys))
+	for i, key := range c.Auth.APIKeys {
+		key.Key = maskLiteral(key.Key)
+		apiKeys[i] = key
+	}
+	c.Auth.APIKeys = apiKeys
+
+	return c
+}
+
+// maskLiteral masks a secret unless it is an environment variable reference
+func maskLiteral(value string) string {
+	if strings.HasPrefix(value, "$") {
+		return value
+	}
+	return mask
+}
+
+var dsnPasswordParam = regexp.MustCompile(`(password=)[^&\s]+`)
+
+// MaskDSN hides the password of a URL, MySQL or key=value connection string
+func MaskDSN(dsn string) string {
+	dsn = dsnPasswordParam.ReplaceAllString(dsn, "${1}"+mask)
+
+	scheme, rest := "", dsn
+	if i := strings.Index(rest, "://"); i >= 0 {
+		scheme, rest = rest[:i+3], rest[i+3:]
+	}
+	at := strings.LastIndex(rest, "@")
+	if at < 0 {
+		return dsn
+	}
+	user, _, hasPassword := strings.Cut(rest[:at], ":")
+	if !hasPassword {
+		return dsn
+	}
+	return scheme + user + ":" + mask + rest[at:]
+}
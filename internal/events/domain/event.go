@@ -10,6 +10,8 @@ var (
 	ErrEventDateRequired = errors.New("Event date is required")
 	ErrEventCapacityZero = errors.New("Event capacity must be greater than zero")
 	ErrEventPriceZero    = errors.New("Event price must be greater than zero")
+	ErrEventNotFound     = errors.New("Event not found")
+	ErrEventHasTickets   = errors.New("Event has sold tickets and cannot be deleted")
 )
 
 type Rating string
@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidEventSort = errors.New("invalid event sort")
+	ErrInvalidCursor    = errors.New("invalid cursor")
+)
+
+type EventSort string
+
+const (
+	EventSortDate  EventSort = "date"
+	EventSortPrice EventSort = "price"
+	EventSortName  EventSort = "name"
+)
+
+func IsValidEventSort(sort EventSort) bool {
+	return sort == EventSortDate || sort == EventSortPrice || sort == EventSortName
+}
+
+// EventFilter narrows down ListEvents, zero values leave a criterion out
+type EventFilter struct {
+	From         time.Time
+	To           time.Time
+	Location     string
+	Organization string
+	Rating       Rating
+	PartnerID    int
+	MinPrice     float64
+	MaxPrice     float64
+	// Name matches events whose name contains it, ignoring case
+	Name       string
+	Sort       EventSort
+	Descending bool
+	// After continues the listing right after the event the cursor was made from
+	After *EventCursor
+	Limit int
+}
+
+type EventPage struct {
+	Events []*Event
+	// Next is nil on the last page
+	Next *EventCursor
+}
+
+// EventCursor marks a position in a listing sorted by Sort, ties are broken by ID
+type EventCursor struct {
+	Sort  EventSort `json:"s"`
+	Value string    `json:"v"`
+	ID    string    `json:"id"`
+}
+
+func NewEventCursor(event *Event, sort EventSort) *EventCursor {
+	cursor := &EventCursor{Sort: sort, ID: event.ID}
+	switch sort {
+	case EventSortPrice:
+		cursor.Value = strconv.FormatFloat(event.Price, 'f', -1, 64)
+	case EventSortName:
+		cursor.Value = event.Name
+	default:
+		cursor.Value = event.Date.UTC().Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+// SortValue returns the cursor value typed like the column it was taken from
+func (c *EventCursor) SortValue() (any, error) {
+	switch c.Sort {
+	case EventSortDate:
+		date, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return date, nil
+	case EventSortPrice:
+		price, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return price, nil
+	case EventSortName:
+		return c.Value, nil
+	default:
+		return nil, ErrInvalidCursor
+	}
+}
+
+// Encode turns the cursor into an opaque token for clients
+func (c *EventCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeEventCursor(token string) (*EventCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor EventCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if _, err := cursor.SortValue(); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrHoldNotFound        = errors.New("Hold not found")
+	ErrHoldExpired         = errors.New("Hold expired")
+	ErrHoldSpotsRequired   = errors.New("Hold requires at least one spot")
+	ErrHoldDurationInvalid = errors.New("Hold duration must be greater than zero")
+	ErrHoldTooManySpots    = errors.New("Hold has more spots than an order allows")
+	ErrHoldSpotDuplicated  = errors.New("Hold lists a spot more than once")
+)
+
+// Hold locks a set of spots for a buyer until ExpiresAt, so they can pay
+// without someone else buying the same spots in the meantime.
+type Hold struct {
+	ID        string
+	EventID   string
+	Email     string
+	Spots     []string
+	ExpiresAt time.Time
+	// BuyerID is the authenticated buyer who placed the hold, only they can buy it
+	BuyerID string
+	// QueueToken is the waiting room token the hold used up, empty when the
+	// event had no waiting room
+	QueueToken string
+}
+
+// NewHold holds at most maxSpots spots, the checkout of a hold buys them all.
+// Zero maxSpots disables the limit.
+func NewHold(event *Event, spots []string, email string, duration time.Duration, maxSpots int) (*Hold, error) {
+	if len(spots) == 0 {
+		return nil, ErrHoldSpotsRequired
+	}
+
+	if maxSpots > 0 && len(spots) > maxSpots {
+		return nil, ErrHoldTooManySpots
+	}
+
+	seen := make(map[string]bool, len(spots))
+	for _, name := range spots {
+		if seen[name] {
+			return nil, ErrHoldSpotDuplicated
+		}
+		seen[name] = true
+	}
+
+	if duration <= 0 {
+		return nil, ErrHoldDurationInvalid
+	}
+
+	return &Hold{
+		ID:        uuid.New().String(),
+		EventID:   event.ID,
+		Email:     email,
+		Spots:     spots,
+		ExpiresAt: time.Now().Add(duration),
+	}, nil
+}
+
+func (h *Hold) IsExpired(now time.Time) bool {
+	return !now.Before(h.ExpiresAt)
+}
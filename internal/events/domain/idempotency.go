@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("Idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("A request with this idempotency key is still being processed")
+)
+
+// IdempotencyRecord is the outcome of the first request sent with a key,
+// replayed to every retry of that request
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Body        []byte
+	Completed   bool
+	CreatedAt   time.Time
+}
+
+type IdempotencyRepository interface {
+	// Reserve stores an incomplete record unless one already exists for its key.
+	// It returns the stored record and whether it was created by this call.
+	Reserve(ctx context.Context, record *IdempotencyRecord) (*IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, statusCode int, body []byte) error
+	// Release removes a record that was not completed, so the key can be retried
+	Release(ctx context.Context, key string) error
+	// DeleteBefore removes records created before t and returns how many were removed
+	DeleteBefore(ctx context.Context, t time.Time) (int, error)
+}
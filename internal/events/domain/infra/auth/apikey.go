@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// APIKey is a static credential of a partner or back-office tool
+type APIKey struct {
+	Name  string
+	Key   string
+	Roles []Role
+}
+
+// APIKeyAuthenticator accepts the keys it was created with in the X-API-Key header
+type APIKeyAuthenticator struct {
+	keys []apiKey
+}
+
+type apiKey struct {
+	hash     [sha256.Size]byte
+	identity Identity
+}
+
+func NewAPIKeyAuthenticator(keys []APIKey) (*APIKeyAuthenticator, error) {
+	authenticator := &APIKeyAuthenticator{}
+	names := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key.Name == "" {
+			return nil, errors.New("api key name is required")
+		}
+		if names[key.Name] {
+			return nil, fmt.Errorf("api key %s: defined more than once", key.Name)
+		}
+		names[key.Name] = true
+
+		if key.Key == "" {
+			return nil, fmt.Errorf("api key %s: key is empty", key.Name)
+		}
+		if len(key.Roles) == 0 {
+			return nil, fmt.Errorf("api key %s: at least one role is required", key.Name)
+		}
+		for _, role := range key.Roles {
+			if !IsValidRole(role) {
+				return nil, fmt.Errorf("api key %s: unknown role %q", key.Name, role)
+			}
+		}
+
+		authenticator.keys = append(authenticator.keys, apiKey{
+			hash: sha256.Sum256([]byte(key.Key)),
+			identity: Identity{
+				Subject: "api_key:" + key.Name,
+				Roles:   key.Roles,
+				Method:  "api_key",
+			},
+		})
+	}
+	return authenticator, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	// Compare hashes in constant time so the response time does not leak keys
+	hash := sha256.Sum256([]byte(key))
+	for _, candidate := range a.keys {
+		if subtle.ConstantTimeCompare(hash[:], candidate.hash[:]) == 1 {
+			identity := candidate.identity
+			return &identity, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
@@ -0,0 +1,95 @@
+// Package auth identifies the callers of the HTTP API. An Authenticator turns
+// the credentials of a request, a JWT bearer token or a static API key, into
+// an Identity whose roles the routes are authorized against.
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+)
+
+type Role string
+
+const (
+	RoleBuyer     Role = "buyer"
+	RoleOrganizer Role = "organizer"
+	RoleAdmin     Role = "admin"
+	RolePartner   Role = "partner"
+)
+
+func IsValidRole(role Role) bool {
+	switch role {
+	case RoleBuyer, RoleOrganizer, RoleAdmin, RolePartner:
+		return true
+	}
+	return false
+}
+
+var (
+	// ErrNoCredentials means the request carries none of the credentials an
+	// Authenticator looks for
+	ErrNoCredentials      = errors.New("Authentication required")
+	ErrInvalidCredentials = errors.New("Invalid credentials")
+	ErrForbidden          = errors.New("Not allowed to perform this operation")
+)
+
+// Identity is an authenticated caller
+type Identity struct {
+	Subject string
+	// Email is the address tickets bought by a buyer are sent to
+	Email string
+	Roles []Role
+	// Method is jwt or api_key
+	Method string
+}
+
+// HasRole reports whether the identity has any of the roles
+func (i *Identity) HasRole(roles ...Role) bool {
+	for _, role := range roles {
+		if slices.Contains(i.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuysForSelf reports whether checkouts of the identity must use its own
+// email. Partners and admins buy on behalf of the email they send.
+func (i *Identity) BuysForSelf() bool {
+	return i.HasRole(RoleBuyer) && !i.HasRole(RolePartner, RoleAdmin)
+}
+
+type Authenticator interface {
+	// Authenticate returns ErrNoCredentials when the request does not carry
+	// its kind of credentials, and ErrInvalidCredentials when they are wrong
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// Chain tries its authenticators in order, the first one finding its kind of
+// credentials decides
+type Chain []Authenticator
+
+func (c Chain) Authenticate(r *http.Request) (*Identity, error) {
+	for _, authenticator := range c {
+		identity, err := authenticator.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return identity, err
+	}
+	return nil, ErrNoCredentials
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the identity of the caller, if it was authenticated
+func FromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok
+}
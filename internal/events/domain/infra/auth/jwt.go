@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MinHMACSecretLength is the size of a SHA-256 output, shorter secrets can
+// be brute forced from a single token
+const MinHMACSecretLength = 32
+
+type JWTConfig struct {
+	// HMACSecret verifies HS256, HS384 and HS512 tokens
+	HMACSecret []byte
+	// JWKS verifies RS*, PS*, ES* and EdDSA tokens
+	JWKS *JWKS
+	// Issuer and Audience, when set, must match the iss and aud claims
+	Issuer   string
+	Audience string
+	// RolesClaim lists the roles of the caller, as an array or a space separated string
+	RolesClaim string
+	// Leeway tolerates clock skew on exp, nbf and iat
+	Leeway time.Duration
+}
+
+// JWTAuthenticator accepts signed tokens with an expiration in the
+// Authorization: Bearer header
+type JWTAuthenticator struct {
+	config JWTConfig
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticator(config JWTConfig) (*JWTAuthenticator, error) {
+	var methods []string
+	if len(config.HMACSecret) > 0 {
+		if len(config.HMACSecret) < MinHMACSecretLength {
+			return nil, fmt.Errorf("hmac secret must have at least %d bytes", MinHMACSecretLength)
+		}
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if config.JWKS != nil {
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA")
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("an hmac secret or a jwks is required")
+	}
+	if config.RolesClaim == "" {
+		config.RolesClaim = "roles"
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(config.Leeway),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+
+	return &JWTAuthenticator{
+		config: config,
+		parser: jwt.NewParser(options...),
+	}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	scheme, raw, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, ErrNoCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(strings.TrimSpace(raw), claims, a.key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: sub claim is required", ErrInvalidCredentials)
+	}
+	email, _ := claims["email"].(string)
+
+	return &Identity{
+		Subject: subject,
+		Email:   email,
+		Roles:   rolesOf(claims[a.config.RolesClaim]),
+		Method:  "jwt",
+	}, nil
+}
+
+// key picks the verification key matching the algorithm of the token, so an
+// HMAC token can never be checked against a public key
+func (a *JWTAuthenticator) key(token *jwt.Token) (any, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return a.config.HMACSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := a.config.JWKS.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	switch token.Method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if _, ok := key.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	case *jwt.SigningMethodECDSA:
+		if _, ok := key.(*ecdsa.PublicKey); ok {
+			return key, nil
+		}
+	case *jwt.SigningMethodEd25519:
+		if _, ok := key.(ed25519.PublicKey); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("key %q does not match algorithm %s", kid, token.Method.Alg())
+}
+
+// rolesOf reads a roles claim, unknown roles are ignored
+func rolesOf(claim any) []Role {
+	var names []string
+	switch value := claim.(type) {
+	case string:
+		names = strings.Fields(value)
+	case []any:
+		for _, item := range value {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	var roles []Role
+	for _, name := range names {
+		if role := Role(name); IsValidRole(role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/auth"
+)
+
+// AuthMiddleware identifies the caller with authenticator and lets through
+// only callers with one of roles. Invalid credentials get a 401, valid ones
+// without a matching role a 403.
+func AuthMiddleware(authenticator auth.Authenticator, roles ...auth.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := authenticator.Authenticate(r)
+			if err != nil {
+				if errors.Is(err, auth.ErrInvalidCredentials) {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				} else {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+				}
+				writeError(w, r, err)
+				return
+			}
+
+			if !identity.HasRole(roles...) {
+				writeError(w, r, auth.ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithIdentity(r.Context(), identity)))
+		})
+	}
+}
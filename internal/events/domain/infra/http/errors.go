@@ -0,0 +1,166 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/auth"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/ratelimit"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
+	"github.com/devfullcycle/imersao18/golang/internal/events/usecase"
+)
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// errorMappings translates known errors into responses, the first match wins.
+// Anything not listed is answered with a generic 500 so driver messages never
+// reach the client.
+var errorMappings = []errorMapping{
+	{auth.ErrNoCredentials, http.StatusUnauthorized, "unauthenticated"},
+	{auth.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+	{auth.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{ratelimit.ErrRateLimited, http.StatusTooManyRequests, "rate_limited"},
+
+	{domain.ErrEventNotFound, http.StatusNotFound, "event_not_found"},
+	{domain.ErrSpotNotFound, http.StatusNotFound, "spot_not_found"},
+	{domain.ErrHoldNotFound, http.StatusNotFound, "hold_not_found"},
+	{domain.ErrWaitingRoomNotFound, http.StatusNotFound, "waiting_room_not_found"},
+	{domain.ErrInvalidWaitingRoomAction, http.StatusNotFound, "waiting_room_action_not_found"},
+	{domain.ErrQueueTokenNotFound, http.StatusNotFound, "queue_token_not_found"},
+
+	{domain.ErrQueueTokenRequired, http.StatusForbidden, "queue_token_required"},
+	{domain.ErrQueueTokenNotAdmitted, http.StatusForbidden, "queue_token_not_admitted"},
+	{domain.ErrQueueTokenExpired, http.StatusGone, "queue_token_expired"},
+
+	{domain.ErrSpotAlreadyReserved, http.StatusConflict, "spot_already_reserved"},
+	{domain.ErrEventHasTickets, http.StatusConflict, "event_has_tickets"},
+	{domain.ErrHoldExpired, http.StatusConflict, "hold_expired"},
+	{domain.ErrIdempotencyKeyInProgress, http.StatusConflict, "idempotency_key_in_progress"},
+	{domain.ErrWaitingRoomNotOpen, http.StatusConflict, "waiting_room_not_open"},
+	{domain.ErrQueueTokenInCheckout, http.StatusConflict, "queue_token_in_checkout"},
+
+	{usecase.ErrInvalidInput, http.StatusUnprocessableEntity, "invalid_input"},
+	{domain.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused"},
+	{domain.ErrEventNameRequired, http.StatusUnprocessableEntity, "event_name_required"},
+	{domain.ErrEventDateRequired, http.StatusUnprocessableEntity, "event_date_required"},
+	{domain.ErrEventCapacityZero, http.StatusUnprocessableEntity, "event_capacity_zero"},
+	{domain.ErrEventPriceZero, http.StatusUnprocessableEntity, "event_price_zero"},
+	{domain.ErrInvalidTicketType, http.StatusUnprocessableEntity, "invalid_ticket_type"},
+	{domain.ErrTicketPriceZero, http.StatusUnprocessableEntity, "ticket_price_zero"},
+	{domain.ErrSpotNameRequired, http.StatusUnprocessableEntity, "spot_name_required"},
+	{domain.ErrInvalidSpotNumber, http.StatusUnprocessableEntity, "invalid_spot_number"},
+	{domain.ErrSpotNameStartLetter, http.StatusUnprocessableEntity, "spot_name_start_letter"},
+	{domain.ErrSpotEndNumber, http.StatusUnprocessableEntity, "spot_name_end_number"},
+	{domain.ErrInvalidQuantity, http.StatusUnprocessableEntity, "invalid_quantity"},
+	{domain.ErrTooManySpots, http.StatusUnprocessableEntity, "too_many_spots"},
+	{domain.ErrSpotsExceedCapacity, http.StatusUnprocessableEntity, "spots_exceed_capacity"},
+	{domain.ErrHoldSpotsRequired, http.StatusUnprocessableEntity, "hold_spots_required"},
+	{domain.ErrHoldTooManySpots, http.StatusUnprocessableEntity, "hold_too_many_spots"},
+	{domain.ErrHoldSpotDuplicated, http.StatusUnprocessableEntity, "hold_spot_duplicated"},
+	{domain.ErrInvalidEventSort, http.StatusUnprocessableEntity, "invalid_event_sort"},
+	{domain.ErrInvalidCursor, http.StatusUnprocessableEntity, "invalid_cursor"},
+	{domain.ErrWaitingRoomSettingsInvalid, http.StatusUnprocessableEntity, "waiting_room_settings_invalid"},
+
+	{usecase.ErrReservationRejected, http.StatusConflict, "reservation_rejected"},
+	{service.ErrPartnerContract, http.StatusBadGateway, "partner_contract_violation"},
+	{service.ErrPartnerUnavailable, http.StatusServiceUnavailable, "partner_unavailable"},
+	{service.ErrPartnerRequestFailed, http.StatusBadGateway, "partner_request_failed"},
+}
+
+func writeJSONError(w http.ResponseWriter, status int, body ErrorBody) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: body})
+}
+
+// writeBadRequest answers a request whose body could not be decoded
+func writeBadRequest(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, ErrorBody{
+			Code:    "request_too_large",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	writeJSONError(w, http.StatusBadRequest, ErrorBody{
+		Code:    "invalid_request",
+		Message: err.Error(),
+	})
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	for _, mapping := range errorMappings {
+		if !errors.Is(err, mapping.err) {
+			continue
+		}
+
+		body := ErrorBody{
+			Code:    mapping.code,
+			Message: mapping.err.Error(),
+			Details: errorDetails(err),
+		}
+		writeJSONError(w, mapping.status, body)
+		return
+	}
+
+	// The client only gets a generic message, keep the cause for us
+	slog.ErrorContext(r.Context(), "unexpected error", "method", r.Method, "path", r.URL.Path, "error", err)
+	writeJSONError(w, http.StatusInternalServerError, ErrorBody{
+		Code:    "internal_error",
+		Message: "internal server error",
+	})
+}
+
+func errorDetails(err error) any {
+	var validationErr *usecase.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Fields
+	}
+
+	var ticketTypeErr *domain.InvalidTicketTypeError
+	if errors.As(err, &ticketTypeErr) {
+		return map[string]any{"ticket_type": ticketTypeErr.TicketType}
+	}
+
+	var rejectedErr *usecase.ReservationRejectedError
+	if errors.As(err, &rejectedErr) {
+		return map[string]any{"failed_spots": rejectedErr.FailedSpots}
+	}
+
+	var rateLimitedErr *ratelimit.RateLimitedError
+	if errors.As(err, &rateLimitedErr) {
+		return map[string]any{"retry_after_seconds": int(math.Ceil(rateLimitedErr.RetryAfter.Seconds()))}
+	}
+
+	var unavailableErr *service.PartnerUnavailableError
+	if errors.As(err, &unavailableErr) {
+		return map[string]any{"retry_after_seconds": int(unavailableErr.RetryAfter.Seconds())}
+	}
+
+	var statusErr *service.PartnerStatusError
+	if errors.As(err, &statusErr) {
+		return map[string]any{"partner_status_code": statusErr.StatusCode}
+	}
+
+	return nil
+}
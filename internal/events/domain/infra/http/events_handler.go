@@ -2,36 +2,73 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/auth"
 	"github.com/devfullcycle/imersao18/golang/internal/events/usecase"
 )
 
 type EventsHandler struct {
-	listEventsUseCase *usecase.ListEventsUseCase
-	listSpotsUseCase  *usecase.ListSpotsUseCase
-	getEventUseCase   *usecase.GetEventUseCase
-	buyTicketsUseCase *usecase.BuyTicketsUseCase
+	listEventsUseCase  *usecase.ListEventsUseCase
+	listSpotsUseCase   *usecase.ListSpotsUseCase
+	getEventUseCase    *usecase.GetEventUseCase
+	buyTicketsUseCase  usecase.BuyTickets
+	createEventUseCase *usecase.CreateEventUseCase
+	updateEventUseCase *usecase.UpdateEventUseCase
+	deleteEventUseCase *usecase.DeleteEventUseCase
+	holdSpotsUseCase   *usecase.HoldSpotsUseCase
 }
 
 func NewEventHandler(
 	listEventsUseCase *usecase.ListEventsUseCase,
 	listSpotsUseCase *usecase.ListSpotsUseCase,
 	getEventUseCase *usecase.GetEventUseCase,
-	buyTicketsUseCase *usecase.BuyTicketsUseCase,
+	buyTicketsUseCase usecase.BuyTickets,
+	createEventUseCase *usecase.CreateEventUseCase,
+	updateEventUseCase *usecase.UpdateEventUseCase,
+	deleteEventUseCase *usecase.DeleteEventUseCase,
+	holdSpotsUseCase *usecase.HoldSpotsUseCase,
 ) *EventsHandler {
 	return &EventsHandler{
-		listEventsUseCase: listEventsUseCase,
-		listSpotsUseCase:  listSpotsUseCase,
-		getEventUseCase:   getEventUseCase,
-		buyTicketsUseCase: buyTicketsUseCase,
+		listEventsUseCase:  listEventsUseCase,
+		listSpotsUseCase:   listSpotsUseCase,
+		getEventUseCase:    getEventUseCase,
+		buyTicketsUseCase:  buyTicketsUseCase,
+		createEventUseCase: createEventUseCase,
+		updateEventUseCase: updateEventUseCase,
+		deleteEventUseCase: deleteEventUseCase,
+		holdSpotsUseCase:   holdSpotsUseCase,
 	}
 }
 
 func (h *EventsHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
-	output, err := h.listEventsUseCase.Execute()
+	query := r.URL.Query()
+	validation := &usecase.ValidationError{}
+	input := usecase.ListEventsInputDTO{
+		From:         query.Get("from"),
+		To:           query.Get("to"),
+		Location:     query.Get("location"),
+		Organization: query.Get("organization"),
+		Rating:       query.Get("rating"),
+		PartnerID:    queryInt(query, "partner_id", validation),
+		MinPrice:     queryFloat(query, "min_price", validation),
+		MaxPrice:     queryFloat(query, "max_price", validation),
+		Name:         query.Get("q"),
+		Sort:         query.Get("sort"),
+		Cursor:       query.Get("cursor"),
+		Limit:        queryInt(query, "limit", validation),
+	}
+	if err := validation.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	output, err := h.listEventsUseCase.Execute(r.Context(), input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -43,43 +80,155 @@ func (h *EventsHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 	eventID := r.PathValue("eventID")
 	input := usecase.GetEventInputDTO{ID: eventID}
 
-	output, err := h.getEventUseCase.Execute(input)
+	output, err := h.getEventUseCase.Execute(r.Context(), input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(output)
+}
+
+func (h *EventsHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
+	var input usecase.CreateEventInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+
+	output, err := h.createEventUseCase.Execute(r.Context(), input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(output)
 }
 
+func (h *EventsHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
+	var input usecase.UpdateEventInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	input.ID = r.PathValue("eventID")
+
+	output, err := h.updateEventUseCase.Execute(r.Context(), input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(output)
+}
+
+func (h *EventsHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
+	eventID := r.PathValue("eventID")
+	input := usecase.DeleteEventInputDTO{ID: eventID}
+
+	if err := h.deleteEventUseCase.Execute(r.Context(), input); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *EventsHandler) ListSpots(w http.ResponseWriter, r *http.Request) {
 	eventID := r.PathValue("eventID")
 	input := usecase.ListSpotsInputDTO{EventID: eventID}
 
-	output, err := h.listSpotsUseCase.Execute(input)
+	output, err := h.listSpotsUseCase.Execute(r.Context(), input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(output)
+}
+
+func (h *EventsHandler) HoldSpots(w http.ResponseWriter, r *http.Request) {
+	var input usecase.HoldSpotsInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	input.EventID = r.PathValue("eventID")
+	input.BuyerID = buyerID(r)
+	if input.QueueToken == "" {
+		input.QueueToken = r.Header.Get(QueueTokenHeader)
+	}
+
+	output, err := h.holdSpotsUseCase.Execute(r.Context(), input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(output)
 }
 
 func (h *EventsHandler) BuyTickets(w http.ResponseWriter, r *http.Request) {
 	var input usecase.BuyTicketInputDTO
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeBadRequest(w, err)
 		return
 	}
+	input.IdempotencyKey = IdempotencyKey(r)
+	input.BuyerID = buyerID(r)
+	if input.QueueToken == "" {
+		input.QueueToken = r.Header.Get(QueueTokenHeader)
+	}
+
+	// Buyers get the tickets at the email they signed in with, whatever the
+	// body says. Partners and admins buy on behalf of the email they send.
+	if identity, ok := auth.FromContext(r.Context()); ok && identity.BuysForSelf() {
+		if identity.Email == "" {
+			writeError(w, r, fmt.Errorf("%w: the token carries no email", auth.ErrForbidden))
+			return
+		}
+		input.Email = identity.Email
+	}
 
-	output, err := h.buyTicketsUseCase.Execute(input)
+	output, err := h.buyTicketsUseCase.Execute(r.Context(), input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(output)
 }
+
+func queryInt(query url.Values, key string, validation *usecase.ValidationError) int {
+	value := query.Get(key)
+	if value == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		validation.Add(key, "must be an integer")
+	}
+	return n
+}
+
+func queryFloat(query url.Values, key string, validation *usecase.ValidationError) float64 {
+	value := query.Get(key)
+	if value == "" {
+		return 0
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		validation.Add(key, "must be a number")
+	}
+	return n
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck reports whether a dependency can serve requests
+type HealthCheck func(ctx context.Context) error
+
+// DefaultHealthCheckTimeout bounds every readiness check
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	checks   map[string]HealthCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewHealthHandler(timeout time.Duration) *HealthHandler {
+	return &HealthHandler{
+		checks:  make(map[string]HealthCheck),
+		timeout: timeout,
+	}
+}
+
+// AddCheck makes readiness depend on check, it must be called before serving
+func (h *HealthHandler) AddCheck(name string, check HealthCheck) {
+	h.checks[name] = check
+}
+
+// Drain makes readiness fail, so load balancers stop sending new requests
+// while the server shuts down
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Liveness answers as long as the process can serve HTTP
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// Readiness runs every check concurrently and fails if any of them does
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		writeHealth(w, http.StatusServiceUnavailable, healthResponse{Status: "draining"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = h.checks[name](ctx)
+		}()
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	response := healthResponse{Status: "ok", Checks: make(map[string]string, len(names))}
+	for i, name := range names {
+		response.Checks[name] = "ok"
+		if errs[i] != nil {
+			status = http.StatusServiceUnavailable
+			response.Status = "unavailable"
+			// Dependency errors can name hosts and users, keep them in the logs
+			response.Checks[name] = "unavailable"
+			slog.WarnContext(r.Context(), "readiness check failed", "check", name, "error", errs[i])
+		}
+	}
+
+	writeHealth(w, status, response)
+}
+
+func writeHealth(w http.ResponseWriter, status int, response healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// MaxBodySize bounds the bodies middlewares read in full before the handler
+const MaxBodySize = 1 << 20
+
+// readBody reads the body of r, at most MaxBodySize bytes, and puts it back
+// for the next handler
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// responseRecorder keeps a copy of what the wrapped handler writes
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// IdempotencyMiddleware stores the first response to a request carrying an
+// Idempotency-Key header and replays it to every retry with the same key. A
+// retry with the same key but a different request is rejected. Server errors
+// and panics are not stored, the key is released so the client can retry.
+// Keys are scoped by clientKey, so clients cannot collide on the same key.
+func IdempotencyMiddleware(repo domain.IdempotencyRepository, clientKey func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get(IdempotencyKeyHeader)
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Another client reusing the key gets a key of its own
+			scoped := sha256.Sum256([]byte(clientKey(r) + "\n" + header))
+			key := hex.EncodeToString(scoped[:])
+			r = r.WithContext(context.WithValue(r.Context(), idempotencyKeyContext{}, key))
+
+			body, err := readBody(w, r)
+			if err != nil {
+				writeBadRequest(w, err)
+				return
+			}
+
+			hash := sha256.New()
+			io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+			hash.Write(body)
+
+			record, created, err := repo.Reserve(r.Context(), &domain.IdempotencyRecord{
+				Key:         key,
+				RequestHash: hex.EncodeToString(hash.Sum(nil)),
+				CreatedAt:   time.Now(),
+			})
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			if !created {
+				replay(w, r, record, hex.EncodeToString(hash.Sum(nil)))
+				return
+			}
+
+			// The key must be settled even when the client already went away
+			settleCtx := context.WithoutCancel(r.Context())
+			recorder := &responseRecorder{ResponseWriter: w}
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					release(settleCtx, repo, key)
+					panic(recovered)
+				}
+			}()
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+
+			if recorder.status >= http.StatusInternalServerError {
+				release(settleCtx, repo, key)
+				return
+			}
+
+			// If this fails the key stays in progress, and retries get a 409 until it expires
+			if err := repo.Complete(settleCtx, key, recorder.status, recorder.body.Bytes()); err != nil {
+				slog.ErrorContext(r.Context(), "complete idempotency key", "error", err)
+			}
+		})
+	}
+}
+
+type idempotencyKeyContext struct{}
+
+// IdempotencyKey is the key IdempotencyMiddleware stored the request under,
+// scoped to its client, empty without an Idempotency-Key header
+func IdempotencyKey(r *http.Request) string {
+	key, _ := r.Context().Value(idempotencyKeyContext{}).(string)
+	return key
+}
+
+func release(ctx context.Context, repo domain.IdempotencyRepository, key string) {
+	if err := repo.Release(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "release idempotency key", "error", err)
+	}
+}
+
+func replay(w http.ResponseWriter, r *http.Request, record *domain.IdempotencyRecord, requestHash string) {
+	if record.RequestHash != requestHash {
+		writeError(w, r, domain.ErrIdempotencyKeyReused)
+		return
+	}
+
+	if !record.Completed {
+		writeError(w, r, domain.ErrIdempotencyKeyInProgress)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
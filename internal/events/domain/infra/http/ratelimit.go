@@ -0,0 +1,97 @@
+package http
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/auth"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/ratelimit"
+)
+
+// ClientKey returns the key rate limits of a caller are kept under: the
+// identity AuthMiddleware authenticated, else the client IP. Credentials that
+// were not checked are never used, a caller sending a new one with each
+// request would get a fresh bucket every time. The X-Forwarded-For header is
+// only trusted when the service runs behind a proxy that sets it, anyone can
+// send it otherwise.
+func ClientKey(trustForwardedFor bool) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		if identity, ok := auth.FromContext(r.Context()); ok {
+			return "user:" + identity.Subject
+		}
+
+		if trustForwardedFor {
+			if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+				client, _, _ := strings.Cut(forwarded, ",")
+				return "ip:" + strings.TrimSpace(client)
+			}
+		}
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		return "ip:" + host
+	}
+}
+
+// RateLimitMiddleware limits the requests of each client to a route
+func RateLimitMiddleware(limiter ratelimit.Limiter, route string, limit ratelimit.Limit, clientKey func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allow(w, r, limiter, route+"|"+clientKey(r), limit) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// EventRateLimitMiddleware limits the checkouts of each client on a single
+// event, the one in the event_id of the JSON body. It is meant to be stricter
+// than the limit of the route, so a bot cannot spend it all on the event
+// everyone is after.
+func EventRateLimitMiddleware(limiter ratelimit.Limiter, limit ratelimit.Limit, clientKey func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := readBody(w, r)
+			if err != nil {
+				writeBadRequest(w, err)
+				return
+			}
+
+			// A body without an event is left to the handler to reject
+			var input struct {
+				EventID string `json:"event_id"`
+			}
+			if json.Unmarshal(body, &input) != nil || input.EventID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if allow(w, r, limiter, "event:"+input.EventID+"|"+clientKey(r), limit) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// allow takes a token for key, or answers 429 with Retry-After. Requests go
+// through when the limiter fails, losing the limit is better than the service.
+func allow(w http.ResponseWriter, r *http.Request, limiter ratelimit.Limiter, key string, limit ratelimit.Limit) bool {
+	decision, err := limiter.Allow(r.Context(), key, limit)
+	if err != nil {
+		slog.WarnContext(r.Context(), "rate limiter failed, letting the request through", "error", err)
+		return true
+	}
+	if decision.Allowed {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+	writeError(w, r, decision.Err())
+	return false
+}
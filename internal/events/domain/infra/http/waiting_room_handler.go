@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/auth"
+	"github.com/devfullcycle/imersao18/golang/internal/events/usecase"
+)
+
+// QueueTokenHeader carries the waiting room token on checkouts and holds, as
+// an alternative to the queue_token field of the body
+const QueueTokenHeader = "X-Queue-Token"
+
+type WaitingRoomHandler struct {
+	getWaitingRoomUseCase     *usecase.GetWaitingRoomUseCase
+	controlWaitingRoomUseCase *usecase.ControlWaitingRoomUseCase
+	joinWaitingRoomUseCase    *usecase.JoinWaitingRoomUseCase
+	getQueueTokenUseCase      *usecase.GetQueueTokenUseCase
+}
+
+func NewWaitingRoomHandler(
+	getWaitingRoomUseCase *usecase.GetWaitingRoomUseCase,
+	controlWaitingRoomUseCase *usecase.ControlWaitingRoomUseCase,
+	joinWaitingRoomUseCase *usecase.JoinWaitingRoomUseCase,
+	getQueueTokenUseCase *usecase.GetQueueTokenUseCase,
+) *WaitingRoomHandler {
+	return &WaitingRoomHandler{
+		getWaitingRoomUseCase:     getWaitingRoomUseCase,
+		controlWaitingRoomUseCase: controlWaitingRoomUseCase,
+		joinWaitingRoomUseCase:    joinWaitingRoomUseCase,
+		getQueueTokenUseCase:      getQueueTokenUseCase,
+	}
+}
+
+func (h *WaitingRoomHandler) GetWaitingRoom(w http.ResponseWriter, r *http.Request) {
+	input := usecase.GetWaitingRoomInputDTO{EventID: r.PathValue("eventID")}
+
+	output, err := h.getWaitingRoomUseCase.Execute(r.Context(), input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(output)
+}
+
+// ControlWaitingRoom runs the open, pause, drain or close action of the path.
+// The body is optional and only read by open.
+func (h *WaitingRoomHandler) ControlWaitingRoom(w http.ResponseWriter, r *http.Request) {
+	var input usecase.ControlWaitingRoomInputDTO
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		writeBadRequest(w, err)
+		return
+	}
+	input.EventID = r.PathValue("eventID")
+	input.Action = r.PathValue("action")
+
+	output, err := h.controlWaitingRoomUseCase.Execute(r.Context(), input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if output == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(output)
+}
+
+func (h *WaitingRoomHandler) JoinWaitingRoom(w http.ResponseWriter, r *http.Request) {
+	input := usecase.JoinWaitingRoomInputDTO{
+		EventID: r.PathValue("eventID"),
+		BuyerID: buyerID(r),
+	}
+
+	output, err := h.joinWaitingRoomUseCase.Execute(r.Context(), input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(output)
+}
+
+// GetQueueToken is polled by waiting buyers until their token is admitted
+func (h *WaitingRoomHandler) GetQueueToken(w http.ResponseWriter, r *http.Request) {
+	input := usecase.GetQueueTokenInputDTO{
+		EventID: r.PathValue("eventID"),
+		Token:   r.PathValue("token"),
+		BuyerID: buyerID(r),
+	}
+
+	output, err := h.getQueueTokenUseCase.Execute(r.Context(), input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(output)
+}
+
+// buyerID is the authenticated caller, empty without authentication
+func buyerID(r *http.Request) string {
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		return identity.Subject
+	}
+	return ""
+}
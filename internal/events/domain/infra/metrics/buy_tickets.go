@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
+	"github.com/devfullcycle/imersao18/golang/internal/events/usecase"
+)
+
+var checkoutFailureReasons = []errorLabel{
+	{usecase.ErrInvalidInput, "invalid_input"},
+	{domain.ErrEventNotFound, "event_not_found"},
+	{domain.ErrHoldNotFound, "hold_not_found"},
+	{domain.ErrHoldExpired, "hold_expired"},
+	{domain.ErrSpotNotFound, "spot_not_found"},
+	{domain.ErrSpotAlreadyReserved, "spot_already_reserved"},
+	{usecase.ErrReservationRejected, "reservation_rejected"},
+	{service.ErrPartnerUnavailable, "partner_unavailable"},
+	{service.ErrPartnerContract, "partner_contract_violation"},
+	{service.ErrPartnerRequestFailed, "partner_request_failed"},
+}
+
+type buyTickets struct {
+	next    usecase.BuyTickets
+	metrics *Metrics
+}
+
+// InstrumentBuyTickets counts checkouts and the tickets they sell. The partner
+// label comes from the output or the usecase.CheckoutError of next, checkouts
+// rejected before the event was found are labelled with partner "unknown".
+func (m *Metrics) InstrumentBuyTickets(next usecase.BuyTickets) usecase.BuyTickets {
+	return &buyTickets{next: next, metrics: m}
+}
+
+func (uc *buyTickets) Execute(ctx context.Context, input usecase.BuyTicketInputDTO) (*usecase.BuyTicketsOutputDTO, error) {
+	output, err := uc.next.Execute(ctx, input)
+
+	partner := "unknown"
+	var checkoutErr *usecase.CheckoutError
+	if output != nil {
+		partner = strconv.Itoa(output.PartnerID)
+	} else if errors.As(err, &checkoutErr) {
+		partner = strconv.Itoa(checkoutErr.PartnerID)
+	}
+	uc.metrics.checkoutAttempts.WithLabelValues(partner).Inc()
+
+	if err != nil {
+		uc.metrics.checkoutFailures.WithLabelValues(partner, errorReason(err, checkoutFailureReasons)).Inc()
+		return nil, err
+	}
+
+	outcome := "confirmed"
+	if len(output.FailedSpots) > 0 {
+		outcome = "partial"
+	}
+	uc.metrics.checkoutSuccesses.WithLabelValues(partner, outcome).Inc()
+
+	for _, ticket := range output.Tickets {
+		uc.metrics.ticketsSold.WithLabelValues(input.EventID, ticket.TicketType).Inc()
+	}
+
+	return output, nil
+}
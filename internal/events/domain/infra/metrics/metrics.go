@@ -0,0 +1,124 @@
+// Package metrics exposes Prometheus metrics. They are collected by decorators
+// around the HTTP handlers, partners, repository and use cases, so the code
+// they wrap knows nothing about them.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+
+	checkoutAttempts  *prometheus.CounterVec
+	checkoutSuccesses *prometheus.CounterVec
+	checkoutFailures  *prometheus.CounterVec
+	ticketsSold       *prometheus.CounterVec
+
+	partnerRequestDuration *prometheus.HistogramVec
+	partnerRequestErrors   *prometheus.CounterVec
+
+	repositoryDuration *prometheus.HistogramVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		checkoutAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "checkout_attempts_total",
+			Help: "Checkouts started, by partner.",
+		}, []string{"partner"}),
+		checkoutSuccesses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "checkout_successes_total",
+			Help: "Checkouts that issued tickets, by partner and outcome (confirmed or partial).",
+		}, []string{"partner", "outcome"}),
+		checkoutFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "checkout_failures_total",
+			Help: "Checkouts that issued no ticket, by partner and failure reason.",
+		}, []string{"partner", "reason"}),
+		ticketsSold: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tickets_sold_total",
+			Help: "Tickets sold by event and ticket type.",
+		}, []string{"event_id", "ticket_type"}),
+		partnerRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "partner_request_duration_seconds",
+			Help:    "Partner call latency by partner, operation and result, retries included.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"partner", "operation", "result"}),
+		partnerRequestErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "partner_request_errors_total",
+			Help: "Failed partner calls by partner, operation and reason.",
+		}, []string{"partner", "operation", "reason"}),
+		repositoryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "repository_operation_duration_seconds",
+			Help:    "Event repository latency by operation and result.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.checkoutAttempts,
+		m.checkoutSuccesses,
+		m.checkoutFailures,
+		m.ticketsSold,
+		m.partnerRequestDuration,
+		m.partnerRequestErrors,
+		m.repositoryDuration,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// result labels an operation outcome
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+func since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
+
+// errorReason returns the label of the first reason err matches, or "internal"
+func errorReason(err error, reasons []errorLabel) string {
+	for _, r := range reasons {
+		if errors.Is(err, r.err) {
+			return r.label
+		}
+	}
+	return "internal"
+}
+
+type errorLabel struct {
+	err   error
+	label string
+}
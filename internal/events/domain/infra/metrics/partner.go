@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
+)
+
+var partnerErrorReasons = []errorLabel{
+	{service.ErrPartnerUnavailable, "unavailable"},
+	{service.ErrPartnerContract, "contract_violation"},
+	{context.DeadlineExceeded, "timeout"},
+	{context.Canceled, "canceled"},
+	{service.ErrPartnerRequestFailed, "request_failed"},
+}
+
+// partnerFactory decorates every partner it creates
+type partnerFactory struct {
+	next    service.PartnerFactory
+	metrics *Metrics
+}
+
+// InstrumentPartnerFactory measures the calls to the partners created by factory
+func (m *Metrics) InstrumentPartnerFactory(factory service.PartnerFactory) service.PartnerFactory {
+	return &partnerFactory{next: factory, metrics: m}
+}
+
+func (f *partnerFactory) CreatePartner(partnerID int) (service.Partner, error) {
+	partner, err := f.next.CreatePartner(partnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedPartner{next: partner, name: strconv.Itoa(partnerID), metrics: f.metrics}, nil
+}
+
+type instrumentedPartner struct {
+	next    service.Partner
+	name    string
+	metrics *Metrics
+}
+
+func (p *instrumentedPartner) MakeReservation(ctx context.Context, request *service.ReservationRequest) ([]service.ReservationResponse, error) {
+	start := time.Now()
+	responses, err := p.next.MakeReservation(ctx, request)
+	p.observe("reserve", start, err)
+	return responses, err
+}
+
+func (p *instrumentedPartner) CancelReservation(ctx context.Context, request *service.CancelReservationRequest) error {
+	start := time.Now()
+	err := p.next.CancelReservation(ctx, request)
+	p.observe("cancel", start, err)
+	return err
+}
+
+func (p *instrumentedPartner) observe(operation string, start time.Time, err error) {
+	p.metrics.partnerRequestDuration.WithLabelValues(p.name, operation, result(err)).Observe(since(start))
+	if err != nil {
+		p.metrics.partnerRequestErrors.WithLabelValues(p.name, operation, errorReason(err, partnerErrorReasons)).Inc()
+	}
+}
+
+func (p *instrumentedPartner) CheckHealth(ctx context.Context) error {
+	return service.CheckPartnerHealth(ctx, p.next)
+}
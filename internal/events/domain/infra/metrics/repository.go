@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+)
+
+type eventRepository struct {
+	next    domain.EventRepository
+	metrics *Metrics
+}
+
+// InstrumentEventRepository times every repository operation, including the
+// ones run inside a transaction
+func (m *Metrics) InstrumentEventRepository(repo domain.EventRepository) domain.EventRepository {
+	return &eventRepository{next: repo, metrics: m}
+}
+
+func (r *eventRepository) observe(operation string, start time.Time, err error) {
+	r.metrics.repositoryDuration.WithLabelValues(operation, result(err)).Observe(since(start))
+}
+
+func (r *eventRepository) ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error) {
+	start := time.Now()
+	page, err := r.next.ListEvents(ctx, filter)
+	r.observe("list_events", start, err)
+	return page, err
+}
+
+func (r *eventRepository) FindEventById(ctx context.Context, eventID string) (*domain.Event, error) {
+	start := time.Now()
+	event, err := r.next.FindEventById(ctx, eventID)
+	r.observe("find_event", start, err)
+	return event, err
+}
+
+func (r *eventRepository) ListPartnerIDs(ctx context.Context) ([]int, error) {
+	start := time.Now()
+	ids, err := r.next.ListPartnerIDs(ctx)
+	r.observe("list_partner_ids", start, err)
+	return ids, err
+}
+
+func (r *eventRepository) FindSpotsByEventID(ctx context.Context, eventID string) ([]*domain.Spot, error) {
+	start := time.Now()
+	spots, err := r.next.FindSpotsByEventID(ctx, eventID)
+	r.observe("find_spots", start, err)
+	return spots, err
+}
+
+func (r *eventRepository) FindSpotByName(ctx context.Context, eventID, spotName string) (*domain.Spot, error) {
+	start := time.Now()
+	spot, err := r.next.FindSpotByName(ctx, eventID, spotName)
+	r.observe("find_spot", start, err)
+	return spot, err
+}
+
+func (r *eventRepository) CreateEvent(ctx context.Context, event *domain.Event) error {
+	start := time.Now()
+	err := r.next.CreateEvent(ctx, event)
+	r.observe("create_event", start, err)
+	return err
+}
+
+func (r *eventRepository) UpdateEvent(ctx context.Context, event *domain.Event) error {
+	start := time.Now()
+	err := r.next.UpdateEvent(ctx, event)
+	r.observe("update_event", start, err)
+	return err
+}
+
+func (r *eventRepository) DeleteEvent(ctx context.Context, eventID string) error {
+	start := time.Now()
+	err := r.next.DeleteEvent(ctx, eventID)
+	r.observe("delete_event", start, err)
+	return err
+}
+
+func (r *eventRepository) CreateSpot(ctx context.Context, spot *domain.Spot) error {
+	start := time.Now()
+	err := r.next.CreateSpot(ctx, spot)
+	r.observe("create_spot", start, err)
+	return err
+}
+
+func (r *eventRepository) CreateTicket(ctx context.Context, ticket *domain.Ticket) error {
+	start := time.Now()
+	err := r.next.CreateTicket(ctx, ticket)
+	r.observe("create_ticket", start, err)
+	return err
+}
+
+func (r *eventRepository) ReserveSpot(ctx context.Context, spotID, ticketID, holdID string) error {
+	start := time.Now()
+	err := r.next.ReserveSpot(ctx, spotID, ticketID, holdID)
+	r.observe("reserve_spot", start, err)
+	return err
+}
+
+func (r *eventRepository) CreateHold(ctx context.Context, hold *domain.Hold) error {
+	start := time.Now()
+	err := r.next.CreateHold(ctx, hold)
+	r.observe("create_hold", start, err)
+	return err
+}
+
+func (r *eventRepository) FindHoldByID(ctx context.Context, holdID string) (*domain.Hold, error) {
+	start := time.Now()
+	hold, err := r.next.FindHoldByID(ctx, holdID)
+	r.observe("find_hold", start, err)
+	return hold, err
+}
+
+func (r *eventRepository) DeleteHold(ctx context.Context, holdID string) error {
+	start := time.Now()
+	err := r.next.DeleteHold(ctx, holdID)
+	r.observe("delete_hold", start, err)
+	return err
+}
+
+func (r *eventRepository) HoldSpot(ctx context.Context, spotID, holdID string, until time.Time) error {
+	start := time.Now()
+	err := r.next.HoldSpot(ctx, spotID, holdID, until)
+	r.observe("hold_spot", start, err)
+	return err
+}
+
+func (r *eventRepository) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	start := time.Now()
+	released, err := r.next.ReleaseExpiredHolds(ctx, now)
+	r.observe("release_expired_holds", start, err)
+	return released, err
+}
+
+func (r *eventRepository) Transaction(ctx context.Context, fn func(ctx context.Context, repo domain.EventRepository) error) error {
+	start := time.Now()
+	err := r.next.Transaction(ctx, func(ctx context.Context, repo domain.EventRepository) error {
+		return fn(ctx, &eventRepository{next: repo, metrics: r.metrics})
+	})
+	r.observe("transaction", start, err)
+	return err
+}
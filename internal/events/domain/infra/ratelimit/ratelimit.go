@@ -0,0 +1,73 @@
+// Package ratelimit throttles callers with token buckets. A Limiter keeps the
+// buckets by key; the in-memory one serves a single instance and a shared
+// store can implement the same interface for several.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRateLimited is returned when a bucket has no token left
+var ErrRateLimited = errors.New("Too many requests")
+
+type RateLimitedError struct {
+	// RetryAfter is how long until the bucket has a token again
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitedError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// Limit lets Requests through every Period, in bursts of up to Burst
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	// Burst defaults to Requests
+	Burst int
+}
+
+// Enabled reports whether the limit restricts anything, a zero limit does not
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// rate is the number of tokens added per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+type Decision struct {
+	Allowed bool
+	// Remaining is the number of requests still allowed right away
+	Remaining int
+	// RetryAfter is set when the request is not allowed
+	RetryAfter time.Duration
+}
+
+// Err returns a RateLimitedError for a request that is not allowed
+func (d Decision) Err() error {
+	if d.Allowed {
+		return nil
+	}
+	return &RateLimitedError{RetryAfter: d.RetryAfter}
+}
+
+type Limiter interface {
+	// Allow takes a token from the bucket of key, created full under limit
+	Allow(ctx context.Context, key string, limit Limit) (Decision, error)
+}
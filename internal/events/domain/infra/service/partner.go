@@ -1,22 +1,112 @@
 package service
 
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrPartnerRequestFailed matches every error caused by a partner call, as
+// opposed to errors in our own data
+var ErrPartnerRequestFailed = errors.New("partner request failed")
+
+// PartnerStatusError is returned when a partner answers with an unexpected HTTP status
+type PartnerStatusError struct {
+	StatusCode int
+}
+
+func (e *PartnerStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+func (e *PartnerStatusError) Is(target error) bool {
+	return target == ErrPartnerRequestFailed
+}
+
+// ErrPartnerContract matches responses that break the partner API contract
+var ErrPartnerContract = errors.New("partner response violates the contract")
+
+type PartnerContractError struct {
+	Reason string
+}
+
+func (e *PartnerContractError) Error() string {
+	return "partner response violates the contract: " + e.Reason
+}
+
+func (e *PartnerContractError) Is(target error) bool {
+	return target == ErrPartnerContract
+}
+
 type ReservationRequest struct {
 	EventID    string   `json:"event_id"`
 	Spots      []string `json:"spots"`
 	TicketType string   `json:"ticket_type"`
 	CardHash   string   `json:"card_hash"`
 	Email      string   `json:"email"`
+	// IdempotencyKey lets a partner recognize a retried reservation, optional
+	IdempotencyKey string `json:"-"`
 }
 
 type ReservationResponse struct {
-	ID         string `json:"id"`
-	Email      string `json:"email"`
-	Spot       string `json:"spot"`
-	TicketType string `json:"ticket_type"`
-	Status     string `json:"status"`
-	EventID    string `json:"event_id"`
+	ID         string            `json:"id"`
+	Email      string            `json:"email"`
+	Spot       string            `json:"spot"`
+	TicketType string            `json:"ticket_type"`
+	Status     ReservationStatus `json:"status"`
+	EventID    string            `json:"event_id"`
+	// Reason explains why a spot was not confirmed, when the partner tells
+	Reason string `json:"reason"`
+}
+
+type CancelReservationRequest struct {
+	EventID        string   `json:"event_id"`
+	ReservationIDs []string `json:"reservation_ids"`
+	Spots          []string `json:"spots"`
 }
 
 type Partner interface {
-	MakeReservation(request *ReservationRequest) ([]ReservationResponse, error)
+	// MakeReservation returns the reservations alongside the error when the
+	// partner answered but some could not be understood. Those have no
+	// status, the partner may have sold them and they must be cancelled.
+	MakeReservation(ctx context.Context, request *ReservationRequest) ([]ReservationResponse, error)
+	// CancelReservation undoes a confirmed reservation, it is used to compensate
+	// a checkout that failed after the partner already sold the spots.
+	CancelReservation(ctx context.Context, request *CancelReservationRequest) error
+}
+
+// CheckReservation cross-checks a partner response against the request: one
+// reservation per requested spot, for the requested event and ticket type. An
+// empty event ID means the partner did not report it. Spots the partner did not
+// confirm are part of a valid response, the caller decides what to do with them.
+func CheckReservation(request *ReservationRequest, responses []ReservationResponse) error {
+	requested := make(map[string]bool, len(request.Spots))
+	for _, spot := range request.Spots {
+		requested[spot] = true
+	}
+
+	returned := make(map[string]bool, len(responses))
+	for _, response := range responses {
+		switch {
+		case !requested[response.Spot]:
+			return &PartnerContractError{Reason: fmt.Sprintf("spot %q was not requested", response.Spot)}
+		case returned[response.Spot]:
+			return &PartnerContractError{Reason: fmt.Sprintf("spot %q returned more than once", response.Spot)}
+		case !IsValidReservationStatus(response.Status):
+			return &PartnerContractError{Reason: fmt.Sprintf("spot %q has unknown status %q", response.Spot, response.Status)}
+		case response.EventID != "" && response.EventID != request.EventID:
+			return &PartnerContractError{Reason: fmt.Sprintf("event %q does not match %q", response.EventID, request.EventID)}
+		case response.TicketType != request.TicketType:
+			return &PartnerContractError{Reason: fmt.Sprintf("ticket type %q does not match %q", response.TicketType, request.TicketType)}
+		}
+		returned[response.Spot] = true
+	}
+
+	for spot := range requested {
+		if !returned[spot] {
+			return &PartnerContractError{Reason: fmt.Sprintf("spot %q is missing", spot)}
+		}
+	}
+
+	return nil
 }
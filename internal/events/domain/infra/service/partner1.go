@@ -2,13 +2,21 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 )
 
+const Partner1Kind = "partner1"
+
 type Partner1 struct {
 	BaseURL string
+	// Token is sent as a bearer token when set
+	Token string
+	// IdempotencyHeader carries the idempotency key of reservations when set
+	IdempotencyHeader string
+	Transport         *PartnerTransport
 }
 
 type Partner1ReservationRequest struct {
@@ -24,9 +32,15 @@ type Partner1ReservationResponse struct {
 	TicketKind string `json:"ticket_kind"`
 	Status     string `json:"status"`
 	EventID    string `json:"event_id"`
+	Reason     string `json:"reason"`
+}
+
+type Partner1CancelRequest struct {
+	ReservationIDs []string `json:"reservations"`
+	Spots          []string `json:"spots"`
 }
 
-func (p *Partner1) MakeReservation(req *ReservationRequest) ([]ReservationResponse, error) {
+func (p *Partner1) MakeReservation(ctx context.Context, req *ReservationRequest) ([]ReservationResponse, error) {
 	// Instanciate partnerRequest
 	partnerRequest := Partner1ReservationRequest{
 		Spots:      req.Spots,
@@ -42,15 +56,20 @@ func (p *Partner1) MakeReservation(req *ReservationRequest) ([]ReservationRespon
 
 	// Create http call
 	url := fmt.Sprintf("%s/events/%s/reserve", p.BaseURL, req.EventID)
-	httpRequest, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 	httpRequest.Header.Set("Content-Type", "application/json")
+	if p.Token != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+	if req.IdempotencyKey != "" && p.IdempotencyHeader != "" {
+		httpRequest.Header.Set(p.IdempotencyHeader, req.IdempotencyKey)
+	}
 
 	// Make call
-	client := &http.Client{}
-	httpResponse, err := client.Do(httpRequest)
+	httpResponse, err := p.Transport.Do(httpRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -58,24 +77,73 @@ func (p *Partner1) MakeReservation(req *ReservationRequest) ([]ReservationRespon
 
 	// Parse response
 	if httpResponse.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", httpResponse.StatusCode)
+		return nil, &PartnerStatusError{StatusCode: httpResponse.StatusCode}
 	}
 
 	// Convert Response
 	var partnerResponse []Partner1ReservationResponse
 	if err := json.NewDecoder(httpResponse.Body).Decode(&partnerResponse); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: invalid response: %w", ErrPartnerRequestFailed, err)
 	}
 
 	// Convert Partner1ReservationResponse to ReservationResponse
 	responses := make([]ReservationResponse, len(partnerResponse))
+	var parseErr error
 	for i, r := range partnerResponse {
 		responses[i] = ReservationResponse{
-			ID:     r.ID,
-			Spot:   r.Spot,
-			Status: r.Status,
+			ID:      r.ID,
+			Email:   r.Email,
+			Spot:    r.Spot,
+			EventID: r.EventID,
+			Reason:  r.Reason,
+		}
+
+		status, err := DefaultStatuses.FromPartner(r.Status)
+		if err == nil {
+			responses[i].TicketType, err = reservationTicketType(nil, r.TicketKind, status, req.TicketType)
+		}
+		if err != nil {
+			if parseErr == nil {
+				parseErr = err
+			}
+			continue
 		}
+		responses[i].Status = status
+	}
+
+	return responses, parseErr
+}
+
+func (p *Partner1) CancelReservation(ctx context.Context, req *CancelReservationRequest) error {
+	partnerRequest := Partner1CancelRequest{
+		ReservationIDs: req.ReservationIDs,
+		Spots:          req.Spots,
+	}
+
+	body, err := json.Marshal(partnerRequest)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/events/%s/cancel", p.BaseURL, req.EventID)
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if p.Token != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+
+	httpResponse, err := p.Transport.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK && httpResponse.StatusCode != http.StatusNoContent {
+		return &PartnerStatusError{StatusCode: httpResponse.StatusCode}
 	}
 
-	return responses, nil
+	return nil
 }
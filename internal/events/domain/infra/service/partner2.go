@@ -2,13 +2,19 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 )
 
+const Partner2Kind = "partner2"
+
 type Partner2 struct {
 	BaseURL string
+	// Token is sent as a bearer token when set
+	Token     string
+	Transport *PartnerTransport
 }
 
 type Partner2ReservationRequest struct {
@@ -24,13 +30,19 @@ type Partner2ReservationResponse struct {
 	TipoIngresso string `json:"tipo_ingresso"`
 	Estado       string `json:"Estado"`
 	EventID      string `json:"evento_id"`
+	Motivo       string `json:"motivo"`
+}
+
+type Partner2CancelRequest struct {
+	Reservas []string `json:"reservas"`
+	Lugares  []string `json:"lugares"`
 }
 
-func (p *Partner2) MakeReservation(req *ReservationRequest) ([]ReservationResponse, error) {
+func (p *Partner2) MakeReservation(ctx context.Context, req *ReservationRequest) ([]ReservationResponse, error) {
 	// Instanciate partnerRequest
 	partnerRequest := Partner2ReservationRequest{
 		Lugares:      req.Spots,
-		TipoIngresso: req.TicketType,
+		TipoIngresso: Partner2TicketTypes.ToPartner(req.TicketType),
 		Email:        req.Email,
 	}
 
@@ -42,15 +54,17 @@ func (p *Partner2) MakeReservation(req *ReservationRequest) ([]ReservationRespon
 
 	// Create http call
 	url := fmt.Sprintf("%s/eventos/%s/reservar", p.BaseURL, req.EventID)
-	httpRequest, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 	httpRequest.Header.Set("Content-Type", "application/json")
+	if p.Token != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+p.Token)
+	}
 
 	// Make call
-	client := &http.Client{}
-	httpResponse, err := client.Do(httpRequest)
+	httpResponse, err := p.Transport.Do(httpRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -58,24 +72,73 @@ func (p *Partner2) MakeReservation(req *ReservationRequest) ([]ReservationRespon
 
 	// Parse response
 	if httpResponse.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", httpResponse.StatusCode)
+		return nil, &PartnerStatusError{StatusCode: httpResponse.StatusCode}
 	}
 
 	// Convert Response
 	var partnerResponse []Partner2ReservationResponse
 	if err := json.NewDecoder(httpResponse.Body).Decode(&partnerResponse); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: invalid response: %w", ErrPartnerRequestFailed, err)
 	}
 
 	// Convert Partner2ReservationResponse to ReservationResponse
 	responses := make([]ReservationResponse, len(partnerResponse))
+	var parseErr error
 	for i, r := range partnerResponse {
 		responses[i] = ReservationResponse{
-			ID:     r.ID,
-			Spot:   r.Lugar,
-			Status: r.Estado,
+			ID:      r.ID,
+			Email:   r.Email,
+			Spot:    r.Lugar,
+			EventID: r.EventID,
+			Reason:  r.Motivo,
+		}
+
+		status, err := Partner2Statuses.FromPartner(r.Estado)
+		if err == nil {
+			responses[i].TicketType, err = reservationTicketType(Partner2TicketTypes, r.TipoIngresso, status, req.TicketType)
 		}
+		if err != nil {
+			if parseErr == nil {
+				parseErr = err
+			}
+			continue
+		}
+		responses[i].Status = status
+	}
+
+	return responses, parseErr
+}
+
+func (p *Partner2) CancelReservation(ctx context.Context, req *CancelReservationRequest) error {
+	partnerRequest := Partner2CancelRequest{
+		Reservas: req.ReservationIDs,
+		Lugares:  req.Spots,
+	}
+
+	body, err := json.Marshal(partnerRequest)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/eventos/%s/cancelar", p.BaseURL, req.EventID)
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if p.Token != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+
+	httpResponse, err := p.Transport.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK && httpResponse.StatusCode != http.StatusNoContent {
+		return &PartnerStatusError{StatusCode: httpResponse.StatusCode}
 	}
 
-	return responses, nil
+	return nil
 }
@@ -0,0 +1,205 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
+	"github.com/devfullcycle/imersao18/golang/internal/fakepartner"
+)
+
+const fakePartnerToken = "secret"
+
+// contractPartners are the hand-written adapters and the fake partner
+// speaking their protocol
+var contractPartners = []struct {
+	kind     string
+	protocol fakepartner.Protocol
+}{
+	{service.Partner1Kind, fakepartner.Partner1Protocol},
+	{service.Partner2Kind, fakepartner.Partner2Protocol},
+}
+
+// newContractPartner starts a fake partner and builds the adapter of kind
+// against it, without retries unless the test asks for them
+func newContractPartner(t *testing.T, kind string, protocol fakepartner.Protocol, config service.TransportConfig) (service.Partner, *fakepartner.Server) {
+	t.Helper()
+
+	server := fakepartner.NewTestServer(protocol, fakePartnerToken)
+	t.Cleanup(server.Close)
+
+	definition := service.PartnerDefinition{
+		ID:          1,
+		Name:        kind,
+		Kind:        kind,
+		BaseURL:     server.URL,
+		Credentials: map[string]string{"token": fakePartnerToken},
+	}
+	partner, err := service.DefaultPartnerRegistry().Create(definition, service.NewPartnerTransport(kind, config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return partner, server.Partner
+}
+
+func contractTransportConfig() service.TransportConfig {
+	config := service.DefaultTransportConfig()
+	config.MaxRetries = 0
+	config.Timeout = time.Second
+	return config
+}
+
+func TestPartnerContract(t *testing.T) {
+	ctx := context.Background()
+	request := func() *service.ReservationRequest {
+		return &service.ReservationRequest{
+			EventID:    "event-1",
+			Spots:      []string{"A1", "A2"},
+			TicketType: "half",
+			CardHash:   "card",
+			Email:      "buyer@example.com",
+		}
+	}
+
+	for _, p := range contractPartners {
+		t.Run(p.kind, func(t *testing.T) {
+			t.Run("confirms free spots", func(t *testing.T) {
+				partner, fake := newContractPartner(t, p.kind, p.protocol, contractTransportConfig())
+
+				responses, err := partner.MakeReservation(ctx, request())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := service.CheckReservation(request(), responses); err != nil {
+					t.Fatal(err)
+				}
+				for _, response := range responses {
+					if response.Status != service.ReservationStatusConfirmed || response.TicketType != "half" || response.ID == "" {
+						t.Errorf("got %+v, want a confirmed half ticket", response)
+					}
+				}
+				if sold := fake.Reservations("event-1"); len(sold) != 2 {
+					t.Errorf("partner sold %d spots, want 2", len(sold))
+				}
+			})
+
+			t.Run("rejects sold spots", func(t *testing.T) {
+				partner, fake := newContractPartner(t, p.kind, p.protocol, contractTransportConfig())
+				fake.MarkSold("event-1", "A2")
+
+				responses, err := partner.MakeReservation(ctx, request())
+				if err != nil {
+					t.Fatal(err)
+				}
+				statuses := map[string]service.ReservationStatus{}
+				for _, response := range responses {
+					statuses[response.Spot] = response.Status
+				}
+				if statuses["A1"] != service.ReservationStatusConfirmed || statuses["A2"] != service.ReservationStatusRejected {
+					t.Errorf("got statuses %v, want A1 confirmed and A2 rejected", statuses)
+				}
+			})
+
+			t.Run("cancels reservations", func(t *testing.T) {
+				partner, fake := newContractPartner(t, p.kind, p.protocol, contractTransportConfig())
+
+				responses, err := partner.MakeReservation(ctx, request())
+				if err != nil {
+					t.Fatal(err)
+				}
+				cancel := &service.CancelReservationRequest{EventID: "event-1"}
+				for _, response := range responses {
+					cancel.ReservationIDs = append(cancel.ReservationIDs, response.ID)
+					cancel.Spots = append(cancel.Spots, response.Spot)
+				}
+				if err := partner.CancelReservation(ctx, cancel); err != nil {
+					t.Fatal(err)
+				}
+				if sold := fake.Reservations("event-1"); len(sold) != 0 {
+					t.Errorf("partner still sells %d spots after the cancellation", len(sold))
+				}
+			})
+
+			for _, failure := range []struct {
+				name     string
+				failures fakepartner.Failures
+			}{
+				{"server error", fakepartner.Failures{StatusCode: 503}},
+				{"malformed JSON", fakepartner.Failures{MalformedJSON: true}},
+				{"latency over the timeout", fakepartner.Failures{Latency: 500 * time.Millisecond}},
+			} {
+				t.Run(failure.name, func(t *testing.T) {
+					config := contractTransportConfig()
+					config.Timeout = 100 * time.Millisecond
+					partner, fake := newContractPartner(t, p.kind, p.protocol, config)
+					fake.SetFailures(failure.failures)
+
+					_, err := partner.MakeReservation(ctx, request())
+					if !errors.Is(err, service.ErrPartnerRequestFailed) {
+						t.Errorf("got %v, want %v", err, service.ErrPartnerRequestFailed)
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestPartner1ReservationRetries retries reservations only for a partner
+// declared to deduplicate them by key
+func TestPartner1ReservationRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		dedupesByKey bool
+	}{
+		{"deduplicated by key", true},
+		{"not deduplicated", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := fakepartner.NewTestServer(fakepartner.Partner1Protocol, fakePartnerToken)
+			t.Cleanup(server.Close)
+			server.Partner.SetFailures(fakepartner.Failures{StatusCode: 503, Times: 1})
+
+			config := contractTransportConfig()
+			config.MaxRetries = 2
+			config.BackoffBase = time.Millisecond
+			factory, err := service.NewPartnerFactory(service.DefaultPartnerRegistry(), []service.PartnerDefinition{{
+				ID:                1,
+				Kind:              service.Partner1Kind,
+				BaseURL:           server.URL,
+				Credentials:       map[string]string{"token": fakePartnerToken},
+				IdempotencyHeader: "Idempotency-Key",
+				DedupesByKey:      tc.dedupesByKey,
+			}}, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			partner, err := factory.CreatePartner(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request := &service.ReservationRequest{
+				EventID:        "event-1",
+				Spots:          []string{"A1"},
+				TicketType:     "full",
+				Email:          "buyer@example.com",
+				IdempotencyKey: "key-1",
+			}
+			responses, err := partner.MakeReservation(context.Background(), request)
+			if !tc.dedupesByKey {
+				if !errors.Is(err, service.ErrPartnerRequestFailed) {
+					t.Errorf("got %v, want the 503 without a retry", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(responses) != 1 || responses[0].Status != service.ReservationStatusConfirmed {
+				t.Errorf("got %+v, want A1 confirmed after a retry", responses)
+			}
+		})
+	}
+}
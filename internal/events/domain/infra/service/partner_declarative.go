@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+)
+
+const DeclarativePartnerKind = "declarative"
+
+// DeclarativePartner talks to a partner according to its PartnerDefinition
+type DeclarativePartner struct {
+	definition  PartnerDefinition
+	transport   *PartnerTransport
+	ticketTypes TicketTypeVocabulary
+	statuses    StatusVocabulary
+}
+
+func NewDeclarativePartner(definition PartnerDefinition, transport *PartnerTransport) (*DeclarativePartner, error) {
+	if err := definition.validateEndpoints(); err != nil {
+		return nil, err
+	}
+
+	ticketTypes := make(TicketTypeVocabulary, len(definition.TicketTypes))
+	for ours, theirs := range definition.TicketTypes {
+		ticketTypes[domain.TicketType(ours)] = theirs
+	}
+
+	statuses := make(StatusVocabulary, len(definition.Reserve.StatusValues))
+	for theirs, ours := range definition.Reserve.StatusValues {
+		statuses[strings.ToLower(theirs)] = ReservationStatus(ours)
+	}
+
+	return &DeclarativePartner{
+		definition:  definition,
+		transport:   transport,
+		ticketTypes: ticketTypes,
+		statuses:    statuses,
+	}, nil
+}
+
+func (p *DeclarativePartner) MakeReservation(ctx context.Context, req *ReservationRequest) ([]ReservationResponse, error) {
+	endpoint := p.definition.Reserve
+	fields := map[string]any{
+		"event_id":    req.EventID,
+		"spots":       req.Spots,
+		"ticket_type": p.ticketTypes.ToPartner(req.TicketType),
+		"card_hash":   req.CardHash,
+		"email":       req.Email,
+	}
+
+	headers := map[string]string{}
+	if req.IdempotencyKey != "" && p.definition.IdempotencyHeader != "" {
+		headers[p.definition.IdempotencyHeader] = req.IdempotencyKey
+	}
+
+	httpResponse, err := p.call(ctx, endpoint, req.EventID, fields, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, &PartnerStatusError{StatusCode: httpResponse.StatusCode}
+	}
+
+	var partnerResponse []map[string]any
+	if err := json.NewDecoder(httpResponse.Body).Decode(&partnerResponse); err != nil {
+		return nil, fmt.Errorf("%w: invalid response: %w", ErrPartnerRequestFailed, err)
+	}
+
+	responses := make([]ReservationResponse, len(partnerResponse))
+	var parseErr error
+	for i, item := range partnerResponse {
+		field := func(name string) string {
+			value, ok := item[endpoint.Response[name]]
+			if !ok || value == nil {
+				return ""
+			}
+			return fmt.Sprint(value)
+		}
+
+		responses[i] = ReservationResponse{
+			ID:      field("id"),
+			Email:   field("email"),
+			Spot:    field("spot"),
+			EventID: field("event_id"),
+			Reason:  field("reason"),
+		}
+
+		// A partner that does not report statuses confirms every spot it returns
+		status := ReservationStatusConfirmed
+		var err error
+		if _, ok := endpoint.Response["status"]; ok {
+			status, err = p.statuses.FromPartner(field("status"))
+		}
+
+		// A partner that does not echo the ticket type sold the requested one
+		word := field("ticket_type")
+		if _, ok := endpoint.Response["ticket_type"]; !ok {
+			word = p.ticketTypes.ToPartner(req.TicketType)
+		}
+
+		if err == nil {
+			responses[i].TicketType, err = reservationTicketType(p.ticketTypes, word, status, req.TicketType)
+		}
+		if err != nil {
+			if parseErr == nil {
+				parseErr = err
+			}
+			continue
+		}
+		responses[i].Status = status
+	}
+
+	return responses, parseErr
+}
+
+func (p *DeclarativePartner) CancelReservation(ctx context.Context, req *CancelReservationRequest) error {
+	if p.definition.Cancel == nil {
+		return fmt.Errorf("partner %d does not support cancellations", p.definition.ID)
+	}
+
+	fields := map[string]any{
+		"event_id":        req.EventID,
+		"reservation_ids": req.ReservationIDs,
+		"spots":           req.Spots,
+	}
+
+	httpResponse, err := p.call(ctx, *p.definition.Cancel, req.EventID, fields, nil)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK && httpResponse.StatusCode != http.StatusNoContent {
+		return &PartnerStatusError{StatusCode: httpResponse.StatusCode}
+	}
+
+	return nil
+}
+
+// call sends the mapped fields to endpoint, with the definition headers plus extra ones
+func (p *DeclarativePartner) call(ctx context.Context, endpoint EndpointDefinition, eventID string, fields map[string]any, extra map[string]string) (*http.Response, error) {
+	partnerRequest := make(map[string]any, len(endpoint.Request))
+	for ours, theirs := range endpoint.Request {
+		partnerRequest[theirs] = fields[ours]
+	}
+
+	body, err := json.Marshal(partnerRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	target := p.expandURL(endpoint.URL, eventID)
+
+	httpRequest, err := http.NewRequestWithContext(ctx, endpoint.Method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	for name, value := range p.definition.Headers {
+		httpRequest.Header.Set(name, p.expandCredentials(value))
+	}
+	for name, value := range extra {
+		httpRequest.Header.Set(name, value)
+	}
+
+	return p.transport.Do(httpRequest)
+}
+
+// expandURL replaces the {base_url} and {event_id} placeholders of an endpoint URL
+func (p *DeclarativePartner) expandURL(endpointURL, eventID string) string {
+	return strings.NewReplacer(
+		"{base_url}", strings.TrimSuffix(p.definition.BaseURL, "/"),
+		"{event_id}", url.PathEscape(eventID),
+	).Replace(endpointURL)
+}
+
+// expandCredentials replaces {credentials.NAME} placeholders in a header value
+func (p *DeclarativePartner) expandCredentials(value string) string {
+	for name := range p.definition.Credentials {
+		value = strings.ReplaceAll(value, "{credentials."+name+"}", p.definition.Credential(name))
+	}
+	return value
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// Fields of ReservationRequest and CancelReservationRequest that a request
+// mapping can send, and fields of ReservationResponse a response mapping can fill
+var (
+	reservationRequestFields  = []string{"event_id", "spots", "ticket_type", "card_hash", "email"}
+	cancelRequestFields       = []string{"event_id", "reservation_ids", "spots"}
+	reservationResponseFields = []string{"id", "email", "spot", "ticket_type", "status", "event_id", "reason"}
+)
+
+// PartnerDefinition configures a partner instance. Kind picks the adapter from
+// the PartnerRegistry; the declarative kind describes the partner HTTP API
+// below, so adding a partner takes configuration instead of a new adapter.
+type PartnerDefinition struct {
+	ID      int    `yaml:"id"`
+	Name    string `yaml:"name"`
+	Kind    string `yaml:"kind"`
+	BaseURL string `yaml:"base_url"`
+	// Credentials values can reference environment variables as $VAR or ${VAR}
+	// to keep secrets out of the file. Declarative headers use them as {credentials.NAME}.
+	Credentials map[string]string `yaml:"credentials"`
+	// Headers are sent on every request
+	Headers map[string]string `yaml:"headers"`
+	// IdempotencyHeader carries the idempotency key of reservations, empty to
+	// not send it
+	IdempotencyHeader string `yaml:"idempotency_header"`
+	// DedupesByKey is set when the partner guarantees that reservations sent
+	// again with the same IdempotencyHeader are applied once. Only then are
+	// reservations retried after a timeout or a 5xx.
+	DedupesByKey bool `yaml:"dedupes_by_key"`
+	// HealthURL is probed by readiness checks, {base_url} by default. Any
+	// answer below 500 counts as healthy.
+	HealthURL string `yaml:"health_url"`
+	// TicketTypes maps our ticket types (half, full) to the partner words for them
+	TicketTypes map[string]string   `yaml:"ticket_types"`
+	Reserve     EndpointDefinition  `yaml:"reserve"`
+	Cancel      *EndpointDefinition `yaml:"cancel"`
+}
+
+type EndpointDefinition struct {
+	Method string `yaml:"method"`
+	// URL may use the {base_url} and {event_id} placeholders
+	URL string `yaml:"url"`
+	// Request maps our field names to the partner JSON field names
+	Request map[string]string `yaml:"request"`
+	// Response maps our field names to the partner JSON field names of each returned item
+	Response map[string]string `yaml:"response"`
+	// StatusValues maps partner status values to confirmed, pending or rejected,
+	// values left out are looked up in DefaultStatuses
+	StatusValues map[string]string `yaml:"status_values"`
+}
+
+type PartnersConfig struct {
+	Partners []PartnerDefinition `yaml:"partners"`
+}
+
+// LoadPartnerDefinitions reads partner definitions from a YAML or JSON file
+func LoadPartnerDefinitions(path string) ([]PartnerDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePartnerDefinitions(data)
+}
+
+// ParsePartnerDefinitions decodes YAML, which JSON is a subset of
+func ParsePartnerDefinitions(data []byte) ([]PartnerDefinition, error) {
+	var config PartnersConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	if err := ValidatePartnerDefinitions(config.Partners); err != nil {
+		return nil, err
+	}
+	return config.Partners, nil
+}
+
+// ValidatePartnerDefinitions validates every definition, filling in their
+// defaults, and rejects IDs used twice
+func ValidatePartnerDefinitions(definitions []PartnerDefinition) error {
+	seen := make(map[int]bool, len(definitions))
+	for i := range definitions {
+		definition := &definitions[i]
+		if seen[definition.ID] {
+			return fmt.Errorf("partner %d: defined more than once", definition.ID)
+		}
+		seen[definition.ID] = true
+
+		if err := definition.Validate(); err != nil {
+			return fmt.Errorf("partner %d: %w", definition.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the settings every kind needs, and fills in defaults
+func (d *PartnerDefinition) Validate() error {
+	if d.ID <= 0 {
+		return errors.New("id must be greater than zero")
+	}
+
+	if d.Kind == "" {
+		d.Kind = DeclarativePartnerKind
+	}
+
+	if d.Name == "" {
+		d.Name = strconv.Itoa(d.ID)
+	}
+
+	if d.BaseURL == "" {
+		return errors.New("base_url is required")
+	}
+
+	if d.DedupesByKey && d.IdempotencyHeader == "" {
+		return errors.New("dedupes_by_key needs idempotency_header")
+	}
+
+	return nil
+}
+
+// Credential returns a credential with environment variables expanded
+func (d *PartnerDefinition) Credential(name string) string {
+	return os.ExpandEnv(d.Credentials[name])
+}
+
+// validateEndpoints checks the HTTP API description used by the declarative kind
+func (d *PartnerDefinition) validateEndpoints() error {
+	if err := d.Reserve.validate("reserve", reservationRequestFields); err != nil {
+		return err
+	}
+	if err := checkFields("reserve.response", d.Reserve.Response, reservationResponseFields); err != nil {
+		return err
+	}
+	if _, ok := d.Reserve.Response["spot"]; !ok {
+		return errors.New("reserve.response must map spot")
+	}
+
+	for value, status := range d.Reserve.StatusValues {
+		if !IsValidReservationStatus(ReservationStatus(status)) {
+			return fmt.Errorf("reserve.status_values: %q maps to unknown status %q", value, status)
+		}
+	}
+
+	if d.Cancel != nil {
+		if err := d.Cancel.validate("cancel", cancelRequestFields); err != nil {
+			return err
+		}
+	}
+
+	for ticketType := range d.TicketTypes {
+		if !domain.IsValidTicketType(domain.TicketType(ticketType)) {
+			return fmt.Errorf("ticket_types: unknown ticket type %q", ticketType)
+		}
+	}
+
+	return nil
+}
+
+func (e *EndpointDefinition) validate(name string, requestFields []string) error {
+	if e.URL == "" {
+		return fmt.Errorf("%s.url is required", name)
+	}
+
+	if e.Method == "" {
+		e.Method = http.MethodPost
+	}
+	e.Method = strings.ToUpper(e.Method)
+
+	return checkFields(name+".request", e.Request, requestFields)
+}
+
+func checkFields(name string, mapping map[string]string, allowed []string) error {
+	for field := range mapping {
+		known := false
+		for _, a := range allowed {
+			if field == a {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("%s: unknown field %q, expected one of %s", name, field, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
@@ -1,33 +1,45 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 )
 
+var ErrPartnerNotFound = errors.New("partner not found")
+
 type PartnerFactory interface {
 	CreatePartner(partnerID int) (Partner, error)
 }
 
 type DefaultPartnerFactory struct {
-	partnerBaseURLs map[int]string
+	partners map[int]Partner
 }
 
-func NewPartnerFactory(partnerBaseURLs map[int]string) PartnerFactory {
-	return &DefaultPartnerFactory{partnerBaseURLs: partnerBaseURLs}
+// NewPartnerFactory builds a partner for every definition up front, so every
+// checkout to a partner shares its timeouts, retries and circuit breaker state
+func NewPartnerFactory(registry *PartnerRegistry, definitions []PartnerDefinition, transportConfig TransportConfig) (PartnerFactory, error) {
+	partners := make(map[int]Partner, len(definitions))
+	for _, definition := range definitions {
+		var opts []PartnerTransportOption
+		if definition.DedupesByKey {
+			opts = append(opts, WithDedupeHeader(definition.IdempotencyHeader))
+		}
+		transport := NewPartnerTransport(definition.Name, transportConfig, opts...)
+		partner, err := registry.Create(definition, transport)
+		if err != nil {
+			return nil, fmt.Errorf("partner %d: %w", definition.ID, err)
+		}
+		partners[definition.ID] = partner
+	}
+
+	return &DefaultPartnerFactory{partners: partners}, nil
 }
 
 func (f *DefaultPartnerFactory) CreatePartner(partnerID int) (Partner, error) {
-	baseURL, ok := f.partnerBaseURLs[partnerID]
+	partner, ok := f.partners[partnerID]
 	if !ok {
-		return nil, fmt.Errorf("invalid partner id: %d", partnerID)
+		return nil, fmt.Errorf("%w: no partner with ID %d", ErrPartnerNotFound, partnerID)
 	}
 
-	switch partnerID {
-	case 1:
-		return &Partner1{BaseURL: baseURL}, nil
-	case 2:
-		return &Partner2{BaseURL: baseURL}, nil
-	default:
-		return nil, fmt.Errorf("partner with ID %d not found", partnerID)
-	}
+	return partner, nil
 }
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"net/http"
+)
+
+// PartnerHealthChecker is implemented by partners that can be probed without
+// side effects, for readiness checks
+type PartnerHealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// CheckPartnerHealth probes partner, the ones that cannot be probed are
+// assumed healthy
+func CheckPartnerHealth(ctx context.Context, partner Partner) error {
+	if checker, ok := partner.(PartnerHealthChecker); ok {
+		return checker.CheckHealth(ctx)
+	}
+	return nil
+}
+
+// probe sends a GET to url, any answer below 500 means the partner is up. It
+// goes through the transport, so an open circuit breaker fails fast.
+func probe(ctx context.Context, transport *PartnerTransport, url string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := transport.Do(request)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+func (p *Partner1) CheckHealth(ctx context.Context) error {
+	return probe(ctx, p.Transport, p.BaseURL)
+}
+
+func (p *Partner2) CheckHealth(ctx context.Context) error {
+	return probe(ctx, p.Transport, p.BaseURL)
+}
+
+func (p *DeclarativePartner) CheckHealth(ctx context.Context) error {
+	url := p.definition.HealthURL
+	if url == "" {
+		url = "{base_url}"
+	}
+	return probe(ctx, p.transport, p.expandURL(url, ""))
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// PartnerConstructor builds a partner of one kind from its definition
+type PartnerConstructor func(definition PartnerDefinition, transport *PartnerTransport) (Partner, error)
+
+// PartnerRegistry maps partner kinds to the adapters implementing them
+type PartnerRegistry struct {
+	mu    sync.RWMutex
+	kinds map[string]PartnerConstructor
+}
+
+func NewPartnerRegistry() *PartnerRegistry {
+	return &PartnerRegistry{kinds: make(map[string]PartnerConstructor)}
+}
+
+// DefaultPartnerRegistry knows the declarative kind and the hand-written
+// Partner1 and Partner2 adapters
+func DefaultPartnerRegistry() *PartnerRegistry {
+	registry := NewPartnerRegistry()
+	registry.Register(DeclarativePartnerKind, func(definition PartnerDefinition, transport *PartnerTransport) (Partner, error) {
+		return NewDeclarativePartner(definition, transport)
+	})
+	registry.Register(Partner1Kind, func(definition PartnerDefinition, transport *PartnerTransport) (Partner, error) {
+		return &Partner1{
+			BaseURL:           definition.BaseURL,
+			Token:             definition.Credential("token"),
+			IdempotencyHeader: definition.IdempotencyHeader,
+			Transport:         transport,
+		}, nil
+	})
+	registry.Register(Partner2Kind, func(definition PartnerDefinition, transport *PartnerTransport) (Partner, error) {
+		return &Partner2{BaseURL: definition.BaseURL, Token: definition.Credential("token"), Transport: transport}, nil
+	})
+	return registry
+}
+
+// Register adds a kind, replacing any constructor registered before under the same name
+func (r *PartnerRegistry) Register(kind string, constructor PartnerConstructor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.kinds[kind] = constructor
+}
+
+func (r *PartnerRegistry) Kinds() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	kinds := make([]string, 0, len(r.kinds))
+	for kind := range r.kinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func (r *PartnerRegistry) Create(definition PartnerDefinition, transport *PartnerTransport) (Partner, error) {
+	r.mu.RLock()
+	constructor, ok := r.kinds[definition.Kind]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown partner kind %q, registered kinds are %v", definition.Kind, r.Kinds())
+	}
+
+	return constructor(definition, transport)
+}
+
+// CheckPartners makes sure the factory can build a partner for each ID,
+// typically every partner_id referenced by an event
+func CheckPartners(factory PartnerFactory, partnerIDs []int) error {
+	var errs []error
+	for _, partnerID := range partnerIDs {
+		if _, err := factory.CreatePartner(partnerID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,131 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service/repository"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service/repository/migrations"
+	"github.com/google/uuid"
+)
+
+// backend is an EventRepository under test, db is nil for the in-memory one
+type backend struct {
+	name string
+	repo domain.EventRepository
+	db   *sql.DB
+}
+
+// forEachBackend runs fn against the in-memory and SQLite repositories, and
+// against MySQL and PostgreSQL when EVENTS_TEST_MYSQL_DSN or
+// EVENTS_TEST_POSTGRES_DSN is set. Those databases are migrated up and every
+// test writes its own events, so they can be reused between runs.
+func forEachBackend(t *testing.T, fn func(t *testing.T, b backend)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, backend{name: "memory", repo: repository.NewMemoryEventRepository()})
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		dsn := "file:" + filepath.Join(t.TempDir(), "events.db") + "?_foreign_keys=on"
+		db := openDatabase(t, "sqlite3", dsn, "sqlite")
+		repo, err := repository.NewSqliteEventRepository(db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(t, backend{name: "sqlite", repo: repo, db: db})
+	})
+
+	for _, external := range []struct {
+		store, driver, env string
+		newRepo            func(*sql.DB) (domain.EventRepository, error)
+	}{
+		{"mysql", "mysql", "EVENTS_TEST_MYSQL_DSN", repository.NewMysqlEventRepository},
+		{"postgres", "postgres", "EVENTS_TEST_POSTGRES_DSN", repository.NewPostgresEventRepository},
+	} {
+		t.Run(external.store, func(t *testing.T) {
+			dsn := os.Getenv(external.env)
+			if dsn == "" {
+				t.Skipf("%s is not set", external.env)
+			}
+			db := openDatabase(t, external.driver, dsn, external.store)
+			repo, err := external.newRepo(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fn(t, backend{name: external.store, repo: repo, db: db})
+		})
+	}
+}
+
+func openDatabase(t *testing.T, driver, dsn, dialect string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.NewMigrator(db, dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("migrate %s: %v", dialect, err)
+	}
+	return db
+}
+
+// createEvent stores an event with spots named A1, A2, ... for a test, after
+// applying options to it
+func createEvent(t *testing.T, repo domain.EventRepository, spots int, options ...func(event *domain.Event)) *domain.Event {
+	t.Helper()
+	ctx := context.Background()
+
+	event := &domain.Event{
+		ID:           uuid.New().String(),
+		Name:         "Conformance " + t.Name(),
+		Location:     "Arena",
+		Organization: uuid.New().String(),
+		Rating:       domain.RatingLivre,
+		Date:         time.Now().Add(30 * 24 * time.Hour).UTC().Truncate(time.Second),
+		ImageURL:     "https://example.com/event.png",
+		Capacity:     spots,
+		Price:        100,
+		PartnerID:    1,
+	}
+	for _, option := range options {
+		option(event)
+	}
+	if err := domain.NewSpotService().GenerateSpots(event, spots); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateEvent(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+	for i := range event.Spots {
+		if err := repo.CreateSpot(ctx, &event.Spots[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return event
+}
+
+// rebind adapts a query with a single ? argument that the tests send straight
+// to the database
+func rebind(store, query string) string {
+	if store == "postgres" {
+		return strings.ReplaceAll(query, "?", "$1")
+	}
+	return query
+}
@@ -0,0 +1,405 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/google/uuid"
+)
+
+// conformanceCases is the behavior every domain.EventRepository must share.
+// Each case creates its own events, so they run against reused databases.
+var conformanceCases = []struct {
+	name string
+	run  func(t *testing.T, repo domain.EventRepository)
+}{
+	{"find event", func(t *testing.T, repo domain.EventRepository) {
+		ctx := context.Background()
+		event := createEvent(t, repo, 2)
+
+		found, err := repo.FindEventById(ctx, event.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Name != event.Name || found.Organization != event.Organization || found.Rating != event.Rating ||
+			!found.Date.Equal(event.Date) || found.Capacity != event.Capacity || found.Price != event.Price ||
+			found.PartnerID != event.PartnerID {
+			t.Errorf("found %+v, want %+v", found, event)
+		}
+
+		if _, err := repo.FindEventById(ctx, uuid.New().String()); !errors.Is(err, domain.ErrEventNotFound) {
+			t.Errorf("unknown event: got %v, want %v", err, domain.ErrEventNotFound)
+		}
+	}},
+	{"update event", func(t *testing.T, repo domain.EventRepository) {
+		ctx := context.Background()
+		event := createEvent(t, repo, 1)
+
+		event.Name = "Renamed"
+		event.Price = 250
+		if err := repo.UpdateEvent(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := repo.FindEventById(ctx, event.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Name != "Renamed" || found.Price != 250 {
+			t.Errorf("found %s at %v, want Renamed at 250", found.Name, found.Price)
+		}
+
+		// Saving an unchanged event is not a missing one
+		if err := repo.UpdateEvent(ctx, event); err != nil {
+			t.Errorf("unchanged event: got %v, want no error", err)
+		}
+
+		missing := *event
+		missing.ID = uuid.New().String()
+		if err := repo.UpdateEvent(ctx, &missing); !errors.Is(err, domain.ErrEventNotFound) {
+			t.Errorf("missing event: got %v, want %v", err, domain.ErrEventNotFound)
+		}
+	}},
+	{"delete event", func(t *testing.T, repo domain.EventRepository) {
+		ctx := context.Background()
+		event := createEvent(t, repo, 2)
+
+		if err := repo.DeleteEvent(ctx, event.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.FindEventById(ctx, event.ID); !errors.Is(err, domain.ErrEventNotFound) {
+			t.Errorf("deleted event: got %v, want %v", err, domain.ErrEventNotFound)
+		}
+		spots, err := repo.FindSpotsByEventID(ctx, event.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(spots) != 0 {
+			t.Errorf("%d spots left after delete", len(spots))
+		}
+	}},
+	{"duplicate ids", func(t *testing.T, repo domain.EventRepository) {
+		ctx := context.Background()
+		event := createEvent(t, repo, 2)
+
+		if err := repo.CreateEvent(ctx, event); !errors.Is(err, domain.ErrAlreadyExists) {
+			t.Errorf("event: got %v, want %v", err, domain.ErrAlreadyExists)
+		}
+
+		spot := event.Spots[0]
+		if err := repo.CreateSpot(ctx, &spot); !errors.Is(err, domain.ErrAlreadyExists) {
+			t.Errorf("spot: got %v, want %v", err, domain.ErrAlreadyExists)
+		}
+		spot.ID = uuid.New().String()
+		if err := repo.CreateSpot(ctx, &spot); !errors.Is(err, domain.ErrAlreadyExists) {
+			t.Errorf("spot name: got %v, want %v", err, domain.ErrAlreadyExists)
+		}
+
+		ticket := createTicket(t, repo, event, &event.Spots[0])
+		duplicate := *ticket
+		duplicate.Spot = &event.Spots[1]
+		if err := repo.CreateTicket(ctx, &duplicate); !errors.Is(err, domain.ErrAlreadyExists) {
+			t.Errorf("ticket: got %v, want %v", err, domain.ErrAlreadyExists)
+		}
+
+		// Nothing was overwritten
+		found, err := repo.FindSpotByName(ctx, event.ID, event.Spots[0].Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.ID != event.Spots[0].ID {
+			t.Errorf("found spot %s, want %s", found.ID, event.Spots[0].ID)
+		}
+	}},
+	{"delete event with tickets", func(t *testing.T, repo domain.EventRepository) {
+		ctx := context.Background()
+		event := createEvent(t, repo, 2)
+		createTicket(t, repo, event, &event.Spots[0])
+
+		if err := repo.DeleteEvent(ctx, event.ID); !errors.Is(err, domain.ErrEventHasTickets) {
+			t.Fatalf("got %v, want %v", err, domain.ErrEventHasTickets)
+		}
+		if _, err := repo.FindEventById(ctx, event.ID); err != nil {
+			t.Errorf("event with tickets: %v", err)
+		}
+		spots, err := repo.FindSpotsByEventID(ctx, event.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(spots) != 2 {
+			t.Errorf("%d spots left, want the 2 of the event", len(spots))
+		}
+	}},
+	{"find spots", func(t *testing.T, repo domain.EventRepository) {
+		ctx := context.Background()
+		event := createEvent(t, repo, 3)
+
+		spots, err := repo.FindSpotsByEventID(ctx, event.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, spot := range spots {
+			names = append(names, spot.Name)
+			if spot.Status != domain.SpotStatusAvailable {
+				t.Errorf("spot %s is %s, want available", spot.Name, spot.Status)
+			}
+		}
+		if want := []string{"A1", "A2", "A3"}; !slices.Equal(names, want) {
+			t.Errorf("spots %v, want %v", names, want)
+		}
+
+		spot, err := repo.FindSpotByName(ctx, event.ID, "A2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if spot.ID != event.Spots[1].ID {
+			t.Errorf("found spot %s, want %s", spot.ID, event.Spots[1].ID)
+		}
+
+		if _, err := repo.FindSpotByName(ctx, event.ID, "Z9"); !errors.Is(err, domain.ErrSpotNotFound) {
+			t.Errorf("unknown spot: got %v, want %v", err, domain.ErrSpotNotFound)
+		}
+	}},
+	{"list events", func(t *testing.T, repo domain.EventRepository) {
+		ctx := context.Background()
+		organization := uuid.New().String()
+		var ids []string
+		for i, name := range []string{"Rock Night", "Jazz Night", "Rock Day"} {
+			event := createEvent(t, repo, 1, func(event *domain.Event) {
+				event.Name = name
+				event.Organization = organization
+				event.Price = float64(10 * (i + 1))
+			})
+			ids = append(ids, event.ID)
+		}
+
+		filter := domain.EventFilter{Organization: organization, Sort: domain.EventSortPrice, Limit: 2}
+		page, err := repo.ListEvents(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := eventIDs(page); !slices.Equal(got, ids[:2]) || page.Next == nil {
+			t.Fatalf("first page %v with next %v, want %v and a next page", got, page.Next, ids[:2])
+		}
+
+		filter.After = page.Next
+		page, err = repo.ListEvents(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := eventIDs(page); !slices.Equal(got, ids[2:]) || page.Next != nil {
+			t.Errorf("last page %v with next %v, want %v and no next page", got, page.Next, ids[2:])
+		}
+
+		page, err = repo.ListEvents(ctx, domain.EventFilter{Organization: organization, Name: "rock", MinPrice: 15})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := eventIDs(page); !slices.Equal(got, ids[2:]) {
+			t.Errorf("filtered %v, want %v", got, ids[2:])
+		}
+
+		if _, err := repo.ListEvents(ctx, domain.EventFilter{Sort: "popularity"}); !errors.Is(err, domain.ErrInvalidEventSort) {
+			t.Errorf("unknown sort: got %v, want %v", err, domain.ErrInvalidEventSort)
+		}
+	}},
+	{"list partner ids", func(t *testing.T, repo domain.EventRepository) {
+		createEvent(t, repo, 1, func(event *domain.Event) { event.PartnerID = 7 })
+
+		partnerIDs, err := repo.ListPartnerIDs(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(partnerIDs, 7) {
+			t.Errorf("partner ids %v do not contain 7", partnerIDs)
+		}
+	}},
+	{"reserve spot", func(t *testing.T, repo domain.EventRepository) {
+		ctx := context.Background()
+		event := createEvent(t, repo, 1)
+		spot := &event.Spots[0]
+
+		ticket := createTicket(t, repo, event, spot)
+		if err := repo.ReserveSpot(ctx, spot.ID, ticket.ID, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := repo.FindSpotByName(ctx, event.ID, spot.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Status != domain.SpotStatusSold || found.TicketID != ticket.ID {
+			t.Errorf("spot is %s with ticket %q, want sold with ticket %q", found.Status, found.TicketID, ticket.ID)
+		}
+
+		if err := repo.ReserveSpot(ctx, spot.ID, uuid.New().String(), ""); !errors.Is(err, domain.ErrSpotAlreadyReserved) {
+			t.Errorf("sold spot: got %v, want %v", err, domain.ErrSpotAlreadyReserved)
+		}
+		if err := repo.ReserveSpot(ctx, uuid.New().String(), ticket.ID, ""); !errors.Is(err, domain.ErrSpotNotFound) {
+			t.Errorf("unknown spot: got %v, want %v", err, domain.ErrSpotNotFound)
+		}
+	}},
+	{"hold spots", func(t *testing.T, repo domain.EventRepository) {
+		ctx := context.Background()
+		event := createEvent(t, repo, 2)
+		hold := createHold(t, repo, event, time.Hour, "A1", "A2")
+
+		found, err := repo.FindHoldByID(ctx, hold.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(found.Spots, []string{"A1", "A2"}) || found.Email != hold.Email ||
+			found.BuyerID != hold.BuyerID || found.QueueToken != hold.QueueToken || !found.ExpiresAt.Equal(hold.ExpiresAt) {
+			t.Errorf("found %+v, want %+v", found, hold)
+		}
+
+		spot := &event.Spots[0]
+		other := uuid.New().String()
+		if err := repo.HoldSpot(ctx, spot.ID, other, time.Now().Add(time.Hour)); !errors.Is(err, domain.ErrSpotAlreadyReserved) {
+			t.Errorf("held spot held again: got %v, want %v", err, domain.ErrSpotAlreadyReserved)
+		}
+		if err := repo.ReserveSpot(ctx, spot.ID, uuid.New().String(), other); !errors.Is(err, domain.ErrSpotAlreadyReserved) {
+			t.Errorf("held spot sold to another hold: got %v, want %v", err, domain.ErrSpotAlreadyReserved)
+		}
+
+		ticket := createTicket(t, repo, event, spot)
+		if err := repo.ReserveSpot(ctx, spot.ID, ticket.ID, hold.ID); err != nil {
+			t.Errorf("held spot sold to its hold: %v", err)
+		}
+
+		if err := repo.DeleteHold(ctx, hold.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.FindHoldByID(ctx, hold.ID); !errors.Is(err, domain.ErrHoldNotFound) {
+			t.Errorf("deleted hold: got %v, want %v", err, domain.ErrHoldNotFound)
+		}
+	}},
+	{"release expired holds", func(t *testing.T, repo domain.EventRepository) {
+		ctx := context.Background()
+		event := createEvent(t, repo, 2)
+		createHold(t, repo, event, -time.Minute, "A1")
+		createHold(t, repo, event, time.Hour, "A2")
+
+		released, err := repo.ReleaseExpiredHolds(ctx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if released < 1 {
+			t.Errorf("released %d spots, want at least 1", released)
+		}
+
+		spots, err := repo.FindSpotsByEventID(ctx, event.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if spots[0].Status != domain.SpotStatusAvailable || spots[1].Status != domain.SpotStatusReserved {
+			t.Errorf("spots are %s and %s, want available and reserved", spots[0].Status, spots[1].Status)
+		}
+	}},
+	{"transaction", func(t *testing.T, repo domain.EventRepository) {
+		ctx := context.Background()
+		event := createEvent(t, repo, 1)
+		spot := &event.Spots[0]
+
+		errRollback := errors.New("rollback")
+		err := repo.Transaction(ctx, func(ctx context.Context, repo domain.EventRepository) error {
+			ticket := createTicket(t, repo, event, spot)
+			if err := repo.ReserveSpot(ctx, spot.ID, ticket.ID, ""); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("got %v, want %v", err, errRollback)
+		}
+
+		found, err := repo.FindSpotByName(ctx, event.ID, spot.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Status != domain.SpotStatusAvailable {
+			t.Errorf("spot is %s after rollback, want available", found.Status)
+		}
+
+		err = repo.Transaction(ctx, func(ctx context.Context, repo domain.EventRepository) error {
+			ticket := createTicket(t, repo, event, spot)
+			return repo.ReserveSpot(ctx, spot.ID, ticket.ID, "")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found, err = repo.FindSpotByName(ctx, event.ID, spot.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.Status != domain.SpotStatusSold {
+			t.Errorf("spot is %s after commit, want sold", found.Status)
+		}
+	}},
+}
+
+func TestEventRepositoryConformance(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		for _, c := range conformanceCases {
+			t.Run(c.name, func(t *testing.T) {
+				c.run(t, b.repo)
+			})
+		}
+	})
+}
+
+func eventIDs(page *domain.EventPage) []string {
+	ids := make([]string, len(page.Events))
+	for i, event := range page.Events {
+		ids[i] = event.ID
+	}
+	return ids
+}
+
+func createTicket(t *testing.T, repo domain.EventRepository, event *domain.Event, spot *domain.Spot) *domain.Ticket {
+	t.Helper()
+
+	ticket, err := domain.NewTicket(event, spot, domain.TicketTypeFull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateTicket(context.Background(), ticket); err != nil {
+		t.Fatal(err)
+	}
+	return ticket
+}
+
+// createHold holds spots of event for duration, a negative one makes an expired hold
+func createHold(t *testing.T, repo domain.EventRepository, event *domain.Event, duration time.Duration, spots ...string) *domain.Hold {
+	t.Helper()
+	ctx := context.Background()
+
+	hold := &domain.Hold{
+		ID:         uuid.New().String(),
+		EventID:    event.ID,
+		Email:      "buyer@example.com",
+		Spots:      spots,
+		ExpiresAt:  time.Now().Add(duration).UTC().Truncate(time.Second),
+		BuyerID:    "buyer",
+		QueueToken: uuid.New().String(),
+	}
+	if err := repo.CreateHold(ctx, hold); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range spots {
+		spot, err := repo.FindSpotByName(ctx, event.ID, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.HoldSpot(ctx, spot.ID, hold.ID, hold.ExpiresAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return hold
+}
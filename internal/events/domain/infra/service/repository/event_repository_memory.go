@@ -0,0 +1,481 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+)
+
+// memoryState holds copies of every stored entity, so callers never share
+// memory with the repository
+type memoryState struct {
+	events  map[string]domain.Event
+	spots   map[string]domain.Spot
+	tickets map[string]domain.Ticket
+	holds   map[string]domain.Hold
+}
+
+func newMemoryState() *memoryState {
+	return &memoryState{
+		events:  make(map[string]domain.Event),
+		spots:   make(map[string]domain.Spot),
+		tickets: make(map[string]domain.Ticket),
+		holds:   make(map[string]domain.Hold),
+	}
+}
+
+func (s *memoryState) clone() *memoryState {
+	c := newMemoryState()
+	for k, v := range s.events {
+		c.events[k] = v
+	}
+	for k, v := range s.spots {
+		c.spots[k] = v
+	}
+	for k, v := range s.tickets {
+		c.tickets[k] = v
+	}
+	for k, v := range s.holds {
+		c.holds[k] = v
+	}
+	return c
+}
+
+type memoryStore struct {
+	mu    sync.RWMutex
+	state *memoryState
+}
+
+type memoryEventRepository struct {
+	store *memoryStore
+	// tx is the working copy of an open transaction, nil outside of one
+	tx *memoryState
+}
+
+func NewMemoryEventRepository() domain.EventRepository {
+	return &memoryEventRepository{
+		store: &memoryStore{state: newMemoryState()},
+	}
+}
+
+func (r *memoryEventRepository) read(fn func(state *memoryState) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return fn(r.store.state)
+}
+
+func (r *memoryEventRepository) write(fn func(state *memoryState) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return fn(r.store.state)
+}
+
+func (r *memoryEventRepository) Transaction(ctx context.Context, fn func(ctx context.Context, repo domain.EventRepository) error) error {
+	// Nested calls join the transaction that is already open
+	if r.tx != nil {
+		return fn(ctx, r)
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	tx := r.store.state.clone()
+	if err := fn(ctx, &memoryEventRepository{store: r.store, tx: tx}); err != nil {
+		return err
+	}
+
+	r.store.state = tx
+	return nil
+}
+
+func (r *memoryEventRepository) ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = domain.EventSortDate
+	}
+	if !domain.IsValidEventSort(filter.Sort) {
+		return nil, domain.ErrInvalidEventSort
+	}
+
+	var after *domain.Event
+	if filter.After != nil {
+		if filter.After.Sort != filter.Sort {
+			return nil, domain.ErrInvalidCursor
+		}
+		value, err := filter.After.SortValue()
+		if err != nil {
+			return nil, err
+		}
+		after = &domain.Event{ID: filter.After.ID}
+		switch v := value.(type) {
+		case time.Time:
+			after.Date = v
+		case float64:
+			after.Price = v
+		case string:
+			after.Name = v
+		}
+	}
+
+	var events []*domain.Event
+	err := r.read(func(state *memoryState) error {
+		for _, event := range state.events {
+			if matchesEventFilter(&event, filter) {
+				event := event
+				events = append(events, &event)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	order := func(a, b *domain.Event) int {
+		c := compareEvents(a, b, filter.Sort)
+		if filter.Descending {
+			return -c
+		}
+		return c
+	}
+	slices.SortFunc(events, order)
+
+	if after != nil {
+		events = slices.DeleteFunc(events, func(event *domain.Event) bool {
+			return order(event, after) <= 0
+		})
+	}
+
+	page := &domain.EventPage{Events: events}
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		page.Events = events[:filter.Limit]
+		page.Next = domain.NewEventCursor(page.Events[filter.Limit-1], filter.Sort)
+	}
+
+	return page, nil
+}
+
+func matchesEventFilter(event *domain.Event, filter domain.EventFilter) bool {
+	switch {
+	case !filter.From.IsZero() && event.Date.Before(filter.From):
+		return false
+	case !filter.To.IsZero() && event.Date.After(filter.To):
+		return false
+	case filter.Location != "" && event.Location != filter.Location:
+		return false
+	case filter.Organization != "" && event.Organization != filter.Organization:
+		return false
+	case filter.Rating != "" && event.Rating != filter.Rating:
+		return false
+	case filter.PartnerID != 0 && event.PartnerID != filter.PartnerID:
+		return false
+	case filter.MinPrice > 0 && event.Price < filter.MinPrice:
+		return false
+	case filter.MaxPrice > 0 && event.Price > filter.MaxPrice:
+		return false
+	case filter.Name != "" && !strings.Contains(strings.ToLower(event.Name), strings.ToLower(filter.Name)):
+		return false
+	}
+	return true
+}
+
+// compareEvents orders events by the sort key, then by ID like the SQL repository
+func compareEvents(a, b *domain.Event, sort domain.EventSort) int {
+	var c int
+	switch sort {
+	case domain.EventSortPrice:
+		c = cmp.Compare(a.Price, b.Price)
+	case domain.EventSortName:
+		c = cmp.Compare(a.Name, b.Name)
+	default:
+		c = a.Date.Compare(b.Date)
+	}
+	if c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
+func (r *memoryEventRepository) FindEventById(ctx context.Context, eventID string) (*domain.Event, error) {
+	var event domain.Event
+	err := r.read(func(state *memoryState) error {
+		found, ok := state.events[eventID]
+		if !ok {
+			return domain.ErrEventNotFound
+		}
+		event = found
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func (r *memoryEventRepository) ListPartnerIDs(ctx context.Context) ([]int, error) {
+	seen := make(map[int]bool)
+	var partnerIDs []int
+	err := r.read(func(state *memoryState) error {
+		for _, event := range state.events {
+			if !seen[event.PartnerID] {
+				seen[event.PartnerID] = true
+				partnerIDs = append(partnerIDs, event.PartnerID)
+			}
+		}
+		return nil
+	})
+
+	sort.Ints(partnerIDs)
+	return partnerIDs, err
+}
+
+func (r *memoryEventRepository) CreateEvent(ctx context.Context, event *domain.Event) error {
+	return r.write(func(state *memoryState) error {
+		if _, ok := state.events[event.ID]; ok {
+			return fmt.Errorf("event %s: %w", event.ID, domain.ErrAlreadyExists)
+		}
+
+		stored := *event
+		stored.Spots = nil
+		stored.Tickets = nil
+		state.events[event.ID] = stored
+		return nil
+	})
+}
+
+func (r *memoryEventRepository) UpdateEvent(ctx context.Context, event *domain.Event) error {
+	return r.write(func(state *memoryState) error {
+		if _, ok := state.events[event.ID]; !ok {
+			return domain.ErrEventNotFound
+		}
+
+		stored := *event
+		stored.Spots = nil
+		stored.Tickets = nil
+		state.events[event.ID] = stored
+		return nil
+	})
+}
+
+func (r *memoryEventRepository) DeleteEvent(ctx context.Context, eventID string) error {
+	return r.write(func(state *memoryState) error {
+		if _, ok := state.events[eventID]; !ok {
+			return domain.ErrEventNotFound
+		}
+
+		for _, ticket := range state.tickets {
+			if ticket.EventID == eventID {
+				return domain.ErrEventHasTickets
+			}
+		}
+		for id, spot := range state.spots {
+			if spot.EventID == eventID {
+				delete(state.spots, id)
+			}
+		}
+		for id, hold := range state.holds {
+			if hold.EventID == eventID {
+				delete(state.holds, id)
+			}
+		}
+		delete(state.events, eventID)
+		return nil
+	})
+}
+
+func (r *memoryEventRepository) FindSpotsByEventID(ctx context.Context, eventID string) ([]*domain.Spot, error) {
+	var spots []*domain.Spot
+	err := r.read(func(state *memoryState) error {
+		for _, spot := range state.spots {
+			if spot.EventID == eventID {
+				spot := spot
+				spots = append(spots, &spot)
+			}
+		}
+		return nil
+	})
+
+	// In the order of the SQL repositories
+	sort.Slice(spots, func(i, j int) bool {
+		if spots[i].Name != spots[j].Name {
+			return spots[i].Name < spots[j].Name
+		}
+		return spots[i].ID < spots[j].ID
+	})
+
+	return spots, err
+}
+
+func (r *memoryEventRepository) FindSpotByName(ctx context.Context, eventID string, name string) (*domain.Spot, error) {
+	var spot *domain.Spot
+	err := r.read(func(state *memoryState) error {
+		for _, s := range state.spots {
+			if s.EventID == eventID && s.Name == name {
+				s := s
+				spot = &s
+				return nil
+			}
+		}
+		return domain.ErrSpotNotFound
+	})
+
+	return spot, err
+}
+
+func (r *memoryEventRepository) CreateSpot(ctx context.Context, spot *domain.Spot) error {
+	return r.write(func(state *memoryState) error {
+		for id, stored := range state.spots {
+			if id == spot.ID || (stored.EventID == spot.EventID && stored.Name == spot.Name) {
+				return fmt.Errorf("spot %s: %w", spot.Name, domain.ErrAlreadyExists)
+			}
+		}
+
+		state.spots[spot.ID] = *spot
+		return nil
+	})
+}
+
+func (r *memoryEventRepository) CreateTicket(ctx context.Context, ticket *domain.Ticket) error {
+	return r.write(func(state *memoryState) error {
+		if _, ok := state.tickets[ticket.ID]; ok {
+			return fmt.Errorf("ticket %s: %w", ticket.ID, domain.ErrAlreadyExists)
+		}
+		for _, stored := range state.tickets {
+			if ticket.Spot != nil && stored.Spot != nil && stored.Spot.ID == ticket.Spot.ID {
+				return domain.ErrSpotAlreadyReserved
+			}
+		}
+
+		stored := *ticket
+		if ticket.Spot != nil {
+			spot := *ticket.Spot
+			stored.Spot = &spot
+		}
+		state.tickets[ticket.ID] = stored
+		return nil
+	})
+}
+
+func (r *memoryEventRepository) ReserveSpot(ctx context.Context, spotID string, ticketID string, holdID string) error {
+	return r.write(func(state *memoryState) error {
+		spot, ok := state.spots[spotID]
+		if !ok {
+			return domain.ErrSpotNotFound
+		}
+
+		now := time.Now()
+		switch {
+		case spot.Status == domain.SpotStatusAvailable:
+		case spot.Status == domain.SpotStatusReserved && (spot.HoldID == holdID || !now.Before(spot.HeldUntil)):
+		default:
+			return domain.ErrSpotAlreadyReserved
+		}
+
+		spot.Status = domain.SpotStatusSold
+		spot.TicketID = ticketID
+		spot.HoldID = ""
+		spot.HeldUntil = time.Time{}
+		state.spots[spotID] = spot
+		return nil
+	})
+}
+
+func (r *memoryEventRepository) HoldSpot(ctx context.Context, spotID string, holdID string, until time.Time) error {
+	return r.write(func(state *memoryState) error {
+		spot, ok := state.spots[spotID]
+		if !ok {
+			return domain.ErrSpotNotFound
+		}
+
+		switch {
+		case spot.Status == domain.SpotStatusAvailable:
+		case spot.Status == domain.SpotStatusReserved && !time.Now().Before(spot.HeldUntil):
+		default:
+			return domain.ErrSpotAlreadyReserved
+		}
+
+		spot.Status = domain.SpotStatusReserved
+		spot.HoldID = holdID
+		spot.HeldUntil = until
+		state.spots[spotID] = spot
+		return nil
+	})
+}
+
+func (r *memoryEventRepository) CreateHold(ctx context.Context, hold *domain.Hold) error {
+	return r.write(func(state *memoryState) error {
+		stored := *hold
+		stored.Spots = nil
+		state.holds[hold.ID] = stored
+		return nil
+	})
+}
+
+func (r *memoryEventRepository) FindHoldByID(ctx context.Context, holdID string) (*domain.Hold, error) {
+	var hold domain.Hold
+	err := r.read(func(state *memoryState) error {
+		found, ok := state.holds[holdID]
+		if !ok {
+			return domain.ErrHoldNotFound
+		}
+		hold = found
+
+		for _, spot := range state.spots {
+			if spot.HoldID == holdID {
+				hold.Spots = append(hold.Spots, spot.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(hold.Spots)
+	return &hold, nil
+}
+
+func (r *memoryEventRepository) DeleteHold(ctx context.Context, holdID string) error {
+	return r.write(func(state *memoryState) error {
+		delete(state.holds, holdID)
+		return nil
+	})
+}
+
+func (r *memoryEventRepository) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	var released int
+	err := r.write(func(state *memoryState) error {
+		for id, spot := range state.spots {
+			if spot.Status == domain.SpotStatusReserved && !now.Before(spot.HeldUntil) {
+				spot.Status = domain.SpotStatusAvailable
+				spot.HoldID = ""
+				spot.HeldUntil = time.Time{}
+				state.spots[id] = spot
+				released++
+			}
+		}
+		for id, hold := range state.holds {
+			if !now.Before(hold.ExpiresAt) {
+				delete(state.holds, id)
+			}
+		}
+		return nil
+	})
+
+	return released, err
+}
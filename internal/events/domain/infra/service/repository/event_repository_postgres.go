@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/lib/pq"
+)
+
+// postgresUniqueViolation is the SQLSTATE of unique_violation
+const postgresUniqueViolation = "23505"
+
+// postgresDialect numbers placeholders as $1, $2, ... No query needs
+// RETURNING: IDs are UUIDs generated before the insert, and conditional
+// updates are checked with RowsAffected like on the other databases.
+type postgresDialect struct{}
+
+func (postgresDialect) rebind(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	inString := false
+	for _, c := range query {
+		switch {
+		case c == '\'':
+			inString = !inString
+		case c == '?' && !inString:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+func (postgresDialect) arg(value any) any {
+	return value
+}
+
+func (postgresDialect) uniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == postgresUniqueViolation
+}
+
+func NewPostgresEventRepository(db *sql.DB) (domain.EventRepository, error) {
+	return newSqlEventRepository(db, postgresDialect{}), nil
+}
+
+func NewPostgresIdempotencyRepository(db *sql.DB) (domain.IdempotencyRepository, error) {
+	return newSqlIdempotencyRepository(db, postgresDialect{}), nil
+}
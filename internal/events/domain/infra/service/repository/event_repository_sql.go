@@ -0,0 +1,669 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+)
+
+// dbtx is the subset of methods shared by *sql.DB and *sql.Tx
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// dialect adapts the queries below, written with ? placeholders, to a driver
+type dialect interface {
+	rebind(query string) string
+	arg(value any) any
+	// uniqueViolation tells whether err is a unique constraint failure
+	uniqueViolation(err error) bool
+}
+
+// dialectConn applies a dialect to every query sent through it
+type dialectConn struct {
+	conn    dbtx
+	dialect dialect
+}
+
+func (c *dialectConn) args(args []any) []any {
+	converted := make([]any, len(args))
+	for i, arg := range args {
+		converted[i] = c.dialect.arg(arg)
+	}
+	return converted
+}
+
+func (c *dialectConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.conn.ExecContext(ctx, c.dialect.rebind(query), c.args(args)...)
+}
+
+func (c *dialectConn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.conn.QueryContext(ctx, c.dialect.rebind(query), c.args(args)...)
+}
+
+func (c *dialectConn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return c.conn.QueryRowContext(ctx, c.dialect.rebind(query), c.args(args)...)
+}
+
+// sqlEventRepository implements domain.EventRepository on top of database/sql,
+// each supported database provides its dialect and constructor
+type sqlEventRepository struct {
+	db      *sql.DB
+	dialect dialect
+	conn    dbtx
+	inTx    bool
+}
+
+func newSqlEventRepository(db *sql.DB, dialect dialect) *sqlEventRepository {
+	return &sqlEventRepository{
+		db:      db,
+		dialect: dialect,
+		conn:    &dialectConn{conn: db, dialect: dialect},
+	}
+}
+
+func (r *sqlEventRepository) Transaction(ctx context.Context, fn func(ctx context.Context, repo domain.EventRepository) error) error {
+	// Nested calls join the transaction that is already open
+	if r.inTx {
+		return fn(ctx, r)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txRepo := &sqlEventRepository{
+		db:      r.db,
+		dialect: r.dialect,
+		conn:    &dialectConn{conn: tx, dialect: r.dialect},
+		inTx:    true,
+	}
+	if err := fn(ctx, txRepo); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// eventSortColumns maps each sort key to the column it orders by
+var eventSortColumns = map[domain.EventSort]string{
+	domain.EventSortDate:  "date",
+	domain.EventSortPrice: "price",
+	domain.EventSortName:  "name",
+}
+
+func (r *sqlEventRepository) ListEvents(ctx context.Context, filter domain.EventFilter) (*domain.EventPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = domain.EventSortDate
+	}
+	column, ok := eventSortColumns[filter.Sort]
+	if !ok {
+		return nil, domain.ErrInvalidEventSort
+	}
+
+	var conditions []string
+	var args []any
+
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "date <= ?")
+		args = append(args, filter.To)
+	}
+	if filter.Location != "" {
+		conditions = append(conditions, "location = ?")
+		args = append(args, filter.Location)
+	}
+	if filter.Organization != "" {
+		conditions = append(conditions, "organization = ?")
+		args = append(args, filter.Organization)
+	}
+	if filter.Rating != "" {
+		conditions = append(conditions, "rating = ?")
+		args = append(args, filter.Rating)
+	}
+	if filter.PartnerID != 0 {
+		conditions = append(conditions, "partner_id = ?")
+		args = append(args, filter.PartnerID)
+	}
+	if filter.MinPrice > 0 {
+		conditions = append(conditions, "price >= ?")
+		args = append(args, filter.MinPrice)
+	}
+	if filter.MaxPrice > 0 {
+		conditions = append(conditions, "price <= ?")
+		args = append(args, filter.MaxPrice)
+	}
+	if filter.Name != "" {
+		conditions = append(conditions, "LOWER(name) LIKE ?")
+		args = append(args, "%"+strings.ToLower(filter.Name)+"%")
+	}
+
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if filter.After != nil {
+		if filter.After.Sort != filter.Sort {
+			return nil, domain.ErrInvalidCursor
+		}
+		value, err := filter.After.SortValue()
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, comparison))
+		args = append(args, value, value, filter.After.ID)
+	}
+
+	query := `
+		SELECT id, name, location, organization, rating, date, image_url, capacity, price, partner_id
+		FROM events
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s", column, direction)
+	if filter.Limit > 0 {
+		// One extra row tells whether there is a next page
+		query += " LIMIT ?"
+		args = append(args, filter.Limit+1)
+	}
+
+	rows, err := r.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*domain.Event
+	for rows.Next() {
+		var event domain.Event
+		err = rows.Scan(
+			&event.ID,
+			&event.Name,
+			&event.Location,
+			&event.Organization,
+			&event.Rating,
+			&event.Date,
+			&event.ImageURL,
+			&event.Capacity,
+			&event.Price,
+			&event.PartnerID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &domain.EventPage{Events: events}
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		page.Events = events[:filter.Limit]
+		page.Next = domain.NewEventCursor(page.Events[filter.Limit-1], filter.Sort)
+	}
+
+	return page, nil
+}
+
+func (r *sqlEventRepository) ListPartnerIDs(ctx context.Context) ([]int, error) {
+	rows, err := r.conn.QueryContext(ctx, `SELECT DISTINCT partner_id FROM events ORDER BY partner_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var partnerIDs []int
+	for rows.Next() {
+		var partnerID int
+		if err := rows.Scan(&partnerID); err != nil {
+			return nil, err
+		}
+		partnerIDs = append(partnerIDs, partnerID)
+	}
+
+	return partnerIDs, rows.Err()
+}
+
+func (r *sqlEventRepository) CreateSpot(ctx context.Context, spot *domain.Spot) error {
+	query := `INSERT INTO spots (id, event_id, name, status, ticket_id, hold_id, held_until) VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := r.conn.ExecContext(ctx, query, spot.ID, spot.EventID, spot.Name, spot.Status, spot.TicketID, spot.HoldID, nullTime(spot.HeldUntil))
+	if err != nil && r.dialect.uniqueViolation(err) {
+		return fmt.Errorf("spot %s: %w", spot.Name, domain.ErrAlreadyExists)
+	}
+
+	return err
+}
+
+func (r *sqlEventRepository) ReserveSpot(ctx context.Context, spotID string, ticketID string, holdID string) error {
+	// Only a spot nobody else is holding can be sold, so concurrent checkouts
+	// for the same spot cannot both succeed
+	query := `
+		UPDATE spots 
+		SET status = ?, ticket_id = ?, hold_id = '', held_until = NULL
+		WHERE id = ? AND (
+			status = ? OR
+			(status = ? AND (hold_id = ? OR held_until <= ?))
+		)
+	`
+	result, err := r.conn.ExecContext(ctx,
+		query,
+		domain.SpotStatusSold,
+		ticketID,
+		spotID,
+		domain.SpotStatusAvailable,
+		domain.SpotStatusReserved,
+		holdID,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.checkSpotUpdated(ctx, spotID, result)
+}
+
+func (r *sqlEventRepository) HoldSpot(ctx context.Context, spotID string, holdID string, until time.Time) error {
+	query := `
+		UPDATE spots
+		SET status = ?, hold_id = ?, held_until = ?
+		WHERE id = ? AND (
+			status = ? OR
+			(status = ? AND held_until <= ?)
+		)
+	`
+	result, err := r.conn.ExecContext(ctx,
+		query,
+		domain.SpotStatusReserved,
+		holdID,
+		until,
+		spotID,
+		domain.SpotStatusAvailable,
+		domain.SpotStatusReserved,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.checkSpotUpdated(ctx, spotID, result)
+}
+
+// checkSpotUpdated turns a conditional spot update that matched no rows into
+// ErrSpotNotFound or ErrSpotAlreadyReserved
+func (r *sqlEventRepository) checkSpotUpdated(ctx context.Context, spotID string, result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var status string
+	err = r.conn.QueryRowContext(ctx, `SELECT status FROM spots WHERE id = ?`, spotID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrSpotNotFound
+		}
+		return err
+	}
+
+	return domain.ErrSpotAlreadyReserved
+}
+
+func (r *sqlEventRepository) CreateHold(ctx context.Context, hold *domain.Hold) error {
+	query := `INSERT INTO holds (id, event_id, email, expires_at, buyer_id, queue_token) VALUES (?, ?, ?, ?, ?, ?)`
+
+	_, err := r.conn.ExecContext(ctx, query, hold.ID, hold.EventID, hold.Email, hold.ExpiresAt, hold.BuyerID, hold.QueueToken)
+
+	return err
+}
+
+func (r *sqlEventRepository) FindHoldByID(ctx context.Context, holdID string) (*domain.Hold, error) {
+	query := `
+		SELECT id, event_id, email, expires_at, buyer_id, queue_token
+		FROM holds
+		WHERE id = ?
+	`
+
+	var hold domain.Hold
+	err := r.conn.QueryRowContext(ctx, query, holdID).Scan(&hold.ID, &hold.EventID, &hold.Email, &hold.ExpiresAt, &hold.BuyerID, &hold.QueueToken)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrHoldNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.conn.QueryContext(ctx, `SELECT name FROM spots WHERE hold_id = ? ORDER BY name`, holdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		hold.Spots = append(hold.Spots, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &hold, nil
+}
+
+func (r *sqlEventRepository) DeleteHold(ctx context.Context, holdID string) error {
+	_, err := r.conn.ExecContext(ctx, `DELETE FROM holds WHERE id = ?`, holdID)
+
+	return err
+}
+
+func (r *sqlEventRepository) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	var released int64
+	err := r.Transaction(ctx, func(ctx context.Context, repo domain.EventRepository) error {
+		conn := repo.(*sqlEventRepository).conn
+
+		query := `
+			UPDATE spots
+			SET status = ?, hold_id = '', held_until = NULL
+			WHERE status = ? AND held_until <= ?
+		`
+		result, err := conn.ExecContext(ctx, query, domain.SpotStatusAvailable, domain.SpotStatusReserved, now)
+		if err != nil {
+			return err
+		}
+
+		released, err = result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		_, err = conn.ExecContext(ctx, `DELETE FROM holds WHERE expires_at <= ?`, now)
+
+		return err
+	})
+
+	return int(released), err
+}
+
+func (r *sqlEventRepository) CreateTicket(ctx context.Context, ticket *domain.Ticket) error {
+	// Both a taken ID and a sold spot violate a unique key, and a failed
+	// statement aborts a PostgreSQL transaction, so the ID is checked first
+	var exists int
+	err := r.conn.QueryRowContext(ctx, `SELECT 1 FROM tickets WHERE id = ?`, ticket.ID).Scan(&exists)
+	if err == nil {
+		return fmt.Errorf("ticket %s: %w", ticket.ID, domain.ErrAlreadyExists)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	query := `INSERT INTO tickets (id, event_id, spot_id, ticket_type, price) VALUES (?, ?, ?, ?, ?)`
+
+	_, err = r.conn.ExecContext(ctx, query, ticket.ID, ticket.EventID, ticket.Spot.ID, ticket.TicketType, ticket.Price)
+	// A concurrent checkout already issued the ticket of this spot
+	if err != nil && r.dialect.uniqueViolation(err) {
+		return domain.ErrSpotAlreadyReserved
+	}
+
+	return err
+}
+
+func (r *sqlEventRepository) FindEventById(ctx context.Context, eventID string) (*domain.Event, error) {
+	query := `
+		SELECT id, name, location, organization, rating, date, image_url, capacity, price, partner_id
+		FROM events 
+		WHERE id = ?
+	`
+
+	row := r.conn.QueryRowContext(ctx, query, eventID)
+
+	var event domain.Event
+	err := row.Scan(
+		&event.ID,
+		&event.Name,
+		&event.Location,
+		&event.Organization,
+		&event.Rating,
+		&event.Date,
+		&event.ImageURL,
+		&event.Capacity,
+		&event.Price,
+		&event.PartnerID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrEventNotFound
+		}
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func (r *sqlEventRepository) CreateEvent(ctx context.Context, event *domain.Event) error {
+	query := `
+		INSERT INTO events (id, name, location, organization, rating, date, image_url, capacity, price, partner_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.conn.ExecContext(ctx,
+		query,
+		event.ID,
+		event.Name,
+		event.Location,
+		event.Organization,
+		event.Rating,
+		event.Date,
+		event.ImageURL,
+		event.Capacity,
+		event.Price,
+		event.PartnerID,
+	)
+	if err != nil && r.dialect.uniqueViolation(err) {
+		return fmt.Errorf("event %s: %w", event.ID, domain.ErrAlreadyExists)
+	}
+
+	return err
+}
+
+func (r *sqlEventRepository) UpdateEvent(ctx context.Context, event *domain.Event) error {
+	query := `
+		UPDATE events
+		SET name = ?, location = ?, organization = ?, rating = ?, date = ?, image_url = ?, capacity = ?, price = ?, partner_id = ?
+		WHERE id = ?
+	`
+
+	result, err := r.conn.ExecContext(ctx,
+		query,
+		event.Name,
+		event.Location,
+		event.Organization,
+		event.Rating,
+		event.Date,
+		event.ImageURL,
+		event.Capacity,
+		event.Price,
+		event.PartnerID,
+		event.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	// MySQL counts only the rows whose values changed, tell an update that
+	// changed nothing from a missing event
+	var exists int
+	err = r.conn.QueryRowContext(ctx, `SELECT 1 FROM events WHERE id = ?`, event.ID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrEventNotFound
+	}
+	return err
+}
+
+func (r *sqlEventRepository) DeleteEvent(ctx context.Context, eventID string) error {
+	return r.Transaction(ctx, func(ctx context.Context, repo domain.EventRepository) error {
+		conn := repo.(*sqlEventRepository).conn
+
+		// Sold tickets are kept, a ticket sold meanwhile makes deleting its
+		// spot fail on the foreign key
+		var tickets int
+		if err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM tickets WHERE event_id = ?`, eventID).Scan(&tickets); err != nil {
+			return err
+		}
+		if tickets > 0 {
+			return domain.ErrEventHasTickets
+		}
+
+		if _, err := conn.ExecContext(ctx, `DELETE FROM spots WHERE event_id = ?`, eventID); err != nil {
+			return err
+		}
+
+		if _, err := conn.ExecContext(ctx, `DELETE FROM holds WHERE event_id = ?`, eventID); err != nil {
+			return err
+		}
+
+		result, err := conn.ExecContext(ctx, `DELETE FROM events WHERE id = ?`, eventID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return domain.ErrEventNotFound
+		}
+
+		return nil
+	})
+}
+
+func (r *sqlEventRepository) FindSpotsByEventID(ctx context.Context, eventID string) ([]*domain.Spot, error) {
+	query := `
+		SELECT id, event_id, name, status, ticket_id, hold_id, held_until
+		FROM spots
+		WHERE event_id = ?
+		ORDER BY name, id
+	`
+
+	rows, err := r.conn.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var spots []*domain.Spot
+	for rows.Next() {
+		var spot domain.Spot
+		var heldUntil sql.NullTime
+		err := rows.Scan(
+			&spot.ID,
+			&spot.EventID,
+			&spot.Name,
+			&spot.Status,
+			&spot.TicketID,
+			&spot.HoldID,
+			&heldUntil,
+		)
+		if err != nil {
+			return nil, err
+		}
+		spot.HeldUntil = heldUntil.Time
+		spots = append(spots, &spot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return spots, nil
+}
+
+func (r *sqlEventRepository) FindSpotByName(ctx context.Context, eventID string, name string) (*domain.Spot, error) {
+	query := `
+		SELECT 
+			s.id, s.event_id, s.name, s.status, s.ticket_id, s.hold_id, s.held_until,
+			t.id, t.event_id, t.spot_id, t.ticket_type, t.price
+		FROM spots s 
+		LEFT JOIN tickets t ON s.id = t.spot_id
+		WHERE s.event_id = ? AND s.name = ?
+	`
+
+	row := r.conn.QueryRowContext(ctx, query, eventID, name)
+
+	var spot domain.Spot
+	var ticket domain.Ticket
+	var ticketID, ticketEventID, ticketSpotID, ticketType sql.NullString
+	var ticketPrice sql.NullFloat64
+	var heldUntil sql.NullTime
+
+	err := row.Scan(
+		&spot.ID,
+		&spot.EventID,
+		&spot.Name,
+		&spot.Status,
+		&spot.TicketID,
+		&spot.HoldID,
+		&heldUntil,
+		&ticketID,
+		&ticketEventID,
+		&ticketSpotID,
+		&ticketType,
+		&ticketPrice,
+	)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrSpotNotFound
+		}
+		return nil, err
+	}
+
+	spot.HeldUntil = heldUntil.Time
+
+	if ticketID.Valid {
+		ticket.ID = ticketID.String
+		ticket.EventID = ticketEventID.String
+		ticket.Spot = &spot
+		ticket.TicketType = domain.TicketType(ticketType.String)
+		ticket.Price = ticketPrice.Float64
+		spot.TicketID = ticket.ID
+	}
+
+	return &spot, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDialect stores times as UTC text, SQLite compares them as strings so
+// every value must use the same offset
+type sqliteDialect struct{}
+
+func (sqliteDialect) rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) arg(value any) any {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC()
+	case sql.NullTime:
+		v.Time = v.Time.UTC()
+		return v
+	}
+	return value
+}
+
+func (sqliteDialect) uniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	// SQLite reports primary keys apart from other unique keys
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+}
+
+func NewSqliteEventRepository(db *sql.DB) (domain.EventRepository, error) {
+	return newSqlEventRepository(db, sqliteDialect{}), nil
+}
+
+func NewSqliteIdempotencyRepository(db *sql.DB) (domain.IdempotencyRepository, error) {
+	return newSqlIdempotencyRepository(db, sqliteDialect{}), nil
+}
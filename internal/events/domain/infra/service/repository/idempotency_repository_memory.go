@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+)
+
+type memoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]domain.IdempotencyRecord
+}
+
+func NewMemoryIdempotencyRepository() domain.IdempotencyRepository {
+	return &memoryIdempotencyRepository{records: make(map[string]domain.IdempotencyRecord)}
+}
+
+func (r *memoryIdempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.records[record.Key]; ok {
+		return &existing, false, nil
+	}
+
+	r.records[record.Key] = *record
+	return record, true, nil
+}
+
+func (r *memoryIdempotencyRepository) Complete(ctx context.Context, key string, statusCode int, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[key]
+	if !ok {
+		return nil
+	}
+
+	record.StatusCode = statusCode
+	record.Body = append([]byte(nil), body...)
+	record.Completed = true
+	r.records[key] = record
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) Release(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if record, ok := r.records[key]; ok && !record.Completed {
+		delete(r.records, key)
+	}
+	return nil
+}
+
+func (r *memoryIdempotencyRepository) DeleteBefore(ctx context.Context, t time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int
+	for key, record := range r.records {
+		if record.CreatedAt.Before(t) {
+			delete(r.records, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
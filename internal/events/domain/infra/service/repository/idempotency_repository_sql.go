@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+)
+
+type sqlIdempotencyRepository struct {
+	conn dbtx
+}
+
+func newSqlIdempotencyRepository(db *sql.DB, dialect dialect) *sqlIdempotencyRepository {
+	return &sqlIdempotencyRepository{conn: &dialectConn{conn: db, dialect: dialect}}
+}
+
+func (r *sqlIdempotencyRepository) find(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	query := `
+		SELECT idempotency_key, request_hash, status_code, body, completed, created_at
+		FROM idempotency_keys
+		WHERE idempotency_key = ?
+	`
+
+	var record domain.IdempotencyRecord
+	err := r.conn.QueryRowContext(ctx, query, key).Scan(
+		&record.Key,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.Body,
+		&record.Completed,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (r *sqlIdempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (idempotency_key, request_hash, status_code, completed, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, insertErr := r.conn.ExecContext(ctx, query, record.Key, record.RequestHash, 0, false, record.CreatedAt)
+	if insertErr == nil {
+		return record, true, nil
+	}
+
+	// The insert most likely hit the primary key, the error text differs per driver
+	// so look the key up instead of parsing it
+	existing, err := r.find(ctx, record.Key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, insertErr
+		}
+		return nil, false, err
+	}
+
+	return existing, false, nil
+}
+
+func (r *sqlIdempotencyRepository) Complete(ctx context.Context, key string, statusCode int, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = ?, body = ?, completed = ?
+		WHERE idempotency_key = ?
+	`
+
+	_, err := r.conn.ExecContext(ctx, query, statusCode, body, true, key)
+
+	return err
+}
+
+func (r *sqlIdempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := r.conn.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE idempotency_key = ? AND completed = ?`, key, false)
+
+	return err
+}
+
+func (r *sqlIdempotencyRepository) DeleteBefore(ctx context.Context, t time.Time) (int, error) {
+	result, err := r.conn.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < ?`, t)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+
+	return int(deleted), err
+}
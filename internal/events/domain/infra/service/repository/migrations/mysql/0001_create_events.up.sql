@@ -0,0 +1,33 @@
+CREATE TABLE events (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    location VARCHAR(255) NOT NULL,
+    organization VARCHAR(255) NOT NULL,
+    rating VARCHAR(8) NOT NULL,
+    date DATETIME NOT NULL,
+    image_url VARCHAR(1024) NOT NULL,
+    capacity INT NOT NULL,
+    price DOUBLE NOT NULL,
+    partner_id INT NOT NULL
+);
+
+CREATE TABLE spots (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    event_id VARCHAR(36) NOT NULL,
+    name VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    ticket_id VARCHAR(36) NOT NULL DEFAULT '',
+    UNIQUE KEY spots_event_id_name (event_id, name),
+    CONSTRAINT spots_event_id_fk FOREIGN KEY (event_id) REFERENCES events (id)
+);
+
+CREATE TABLE tickets (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    event_id VARCHAR(36) NOT NULL,
+    spot_id VARCHAR(36) NOT NULL,
+    ticket_type VARCHAR(8) NOT NULL,
+    price DOUBLE NOT NULL,
+    UNIQUE KEY tickets_spot_id (spot_id),
+    CONSTRAINT tickets_event_id_fk FOREIGN KEY (event_id) REFERENCES events (id),
+    CONSTRAINT tickets_spot_id_fk FOREIGN KEY (spot_id) REFERENCES spots (id)
+);
@@ -0,0 +1,6 @@
+ALTER TABLE spots
+    DROP INDEX spots_hold_id,
+    DROP COLUMN held_until,
+    DROP COLUMN hold_id;
+
+DROP TABLE holds;
@@ -0,0 +1,13 @@
+CREATE TABLE holds (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    event_id VARCHAR(36) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    INDEX holds_expires_at (expires_at),
+    CONSTRAINT holds_event_id_fk FOREIGN KEY (event_id) REFERENCES events (id)
+);
+
+ALTER TABLE spots
+    ADD COLUMN hold_id VARCHAR(36) NOT NULL DEFAULT '',
+    ADD COLUMN held_until DATETIME NULL,
+    ADD INDEX spots_hold_id (hold_id);
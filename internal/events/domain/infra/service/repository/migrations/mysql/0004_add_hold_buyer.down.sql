@@ -0,0 +1,3 @@
+ALTER TABLE holds
+    DROP COLUMN queue_token,
+    DROP COLUMN buyer_id;
@@ -0,0 +1,3 @@
+ALTER TABLE holds
+    ADD COLUMN buyer_id VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN queue_token VARCHAR(36) NOT NULL DEFAULT '';
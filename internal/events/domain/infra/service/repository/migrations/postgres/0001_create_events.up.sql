@@ -0,0 +1,30 @@
+CREATE TABLE events (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    location VARCHAR(255) NOT NULL,
+    organization VARCHAR(255) NOT NULL,
+    rating VARCHAR(8) NOT NULL,
+    date TIMESTAMPTZ NOT NULL,
+    image_url VARCHAR(1024) NOT NULL,
+    capacity INTEGER NOT NULL,
+    price DOUBLE PRECISION NOT NULL,
+    partner_id INTEGER NOT NULL
+);
+
+CREATE TABLE spots (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    event_id VARCHAR(36) NOT NULL REFERENCES events (id),
+    name VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    ticket_id VARCHAR(36) NOT NULL DEFAULT '',
+    CONSTRAINT spots_event_id_name UNIQUE (event_id, name)
+);
+
+CREATE TABLE tickets (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    event_id VARCHAR(36) NOT NULL REFERENCES events (id),
+    spot_id VARCHAR(36) NOT NULL REFERENCES spots (id),
+    ticket_type VARCHAR(8) NOT NULL,
+    price DOUBLE PRECISION NOT NULL,
+    CONSTRAINT tickets_spot_id UNIQUE (spot_id)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE holds (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    event_id VARCHAR(36) NOT NULL REFERENCES events (id),
+    email VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX holds_expires_at ON holds (expires_at);
+
+ALTER TABLE spots
+    ADD COLUMN hold_id VARCHAR(36) NOT NULL DEFAULT '',
+    ADD COLUMN held_until TIMESTAMPTZ NULL;
+
+CREATE INDEX spots_hold_id ON spots (hold_id);
@@ -0,0 +1,29 @@
+CREATE TABLE events (
+    id TEXT NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL,
+    location TEXT NOT NULL,
+    organization TEXT NOT NULL,
+    rating TEXT NOT NULL,
+    date DATETIME NOT NULL,
+    image_url TEXT NOT NULL,
+    capacity INTEGER NOT NULL,
+    price REAL NOT NULL,
+    partner_id INTEGER NOT NULL
+);
+
+CREATE TABLE spots (
+    id TEXT NOT NULL PRIMARY KEY,
+    event_id TEXT NOT NULL REFERENCES events (id),
+    name TEXT NOT NULL,
+    status TEXT NOT NULL,
+    ticket_id TEXT NOT NULL DEFAULT '',
+    UNIQUE (event_id, name)
+);
+
+CREATE TABLE tickets (
+    id TEXT NOT NULL PRIMARY KEY,
+    event_id TEXT NOT NULL REFERENCES events (id),
+    spot_id TEXT NOT NULL UNIQUE REFERENCES spots (id),
+    ticket_type TEXT NOT NULL,
+    price REAL NOT NULL
+);
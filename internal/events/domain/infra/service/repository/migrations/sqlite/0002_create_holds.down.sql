@@ -0,0 +1,7 @@
+DROP INDEX spots_hold_id;
+
+ALTER TABLE spots DROP COLUMN held_until;
+
+ALTER TABLE spots DROP COLUMN hold_id;
+
+DROP TABLE holds;
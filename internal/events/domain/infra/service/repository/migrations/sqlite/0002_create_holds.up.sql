@@ -0,0 +1,14 @@
+CREATE TABLE holds (
+    id TEXT NOT NULL PRIMARY KEY,
+    event_id TEXT NOT NULL REFERENCES events (id),
+    email TEXT NOT NULL,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX holds_expires_at ON holds (expires_at);
+
+ALTER TABLE spots ADD COLUMN hold_id TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE spots ADD COLUMN held_until DATETIME NULL;
+
+CREATE INDEX spots_hold_id ON spots (hold_id);
@@ -0,0 +1,3 @@
+ALTER TABLE holds DROP COLUMN queue_token;
+
+ALTER TABLE holds DROP COLUMN buyer_id;
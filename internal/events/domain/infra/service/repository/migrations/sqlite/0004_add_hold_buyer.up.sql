@@ -0,0 +1,3 @@
+ALTER TABLE holds ADD COLUMN buyer_id TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE holds ADD COLUMN queue_token TEXT NOT NULL DEFAULT '';
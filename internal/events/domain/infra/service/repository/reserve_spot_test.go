@@ -0,0 +1,87 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+)
+
+// TestReserveSpotConcurrently runs many checkouts of the same spot at once,
+// the way BuyTicketsUseCase stores them, and expects exactly one to sell it
+func TestReserveSpotConcurrently(t *testing.T) {
+	const buyers = 50
+
+	forEachBackend(t, func(t *testing.T, b backend) {
+		ctx := context.Background()
+		event := createEvent(t, b.repo, 1)
+		spotName := event.Spots[0].Name
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		ticketIDs := make([]string, buyers)
+		errs := make([]error, buyers)
+		for i := range buyers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				errs[i] = b.repo.Transaction(ctx, func(ctx context.Context, repo domain.EventRepository) error {
+					spot, err := repo.FindSpotByName(ctx, event.ID, spotName)
+					if err != nil {
+						return err
+					}
+					ticket, err := domain.NewTicket(event, spot, domain.TicketTypeFull)
+					if err != nil {
+						return err
+					}
+					if err := repo.CreateTicket(ctx, ticket); err != nil {
+						return err
+					}
+					ticketIDs[i] = ticket.ID
+					return repo.ReserveSpot(ctx, spot.ID, ticket.ID, "")
+				})
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		winner := ""
+		for i, err := range errs {
+			switch {
+			case err == nil:
+				if winner != "" {
+					t.Fatalf("spot sold twice, to tickets %s and %s", winner, ticketIDs[i])
+				}
+				winner = ticketIDs[i]
+			case !errors.Is(err, domain.ErrSpotAlreadyReserved):
+				t.Errorf("buyer %d: got %v, want %v", i, err, domain.ErrSpotAlreadyReserved)
+			}
+		}
+		if winner == "" {
+			t.Fatal("no buyer got the spot")
+		}
+
+		spot, err := b.repo.FindSpotByName(ctx, event.ID, spotName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if spot.Status != domain.SpotStatusSold || spot.TicketID != winner {
+			t.Errorf("spot is %s with ticket %q, want sold with ticket %q", spot.Status, spot.TicketID, winner)
+		}
+
+		// The tickets of the losers were rolled back with their transaction
+		if b.db != nil {
+			var tickets int
+			err := b.db.QueryRowContext(ctx, rebind(b.name, `SELECT COUNT(*) FROM tickets WHERE event_id = ?`), event.ID).Scan(&tickets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tickets != 1 {
+				t.Errorf("%d tickets stored, want 1", tickets)
+			}
+		}
+	})
+}
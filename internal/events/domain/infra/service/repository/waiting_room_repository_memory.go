@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+)
+
+// memoryWaitingRoomRepository keeps the queues of a single instance, every
+// instance behind a load balancer would run its own
+type memoryWaitingRoomRepository struct {
+	mu    sync.Mutex
+	rooms map[string]*domain.WaitingRoom
+}
+
+func NewMemoryWaitingRoomRepository() domain.WaitingRoomRepository {
+	return &memoryWaitingRoomRepository{rooms: make(map[string]*domain.WaitingRoom)}
+}
+
+func (r *memoryWaitingRoomRepository) Update(ctx context.Context, eventID string, fn func(room *domain.WaitingRoom) (*domain.WaitingRoom, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// fn works on a copy, so a failed update leaves the stored room as it was
+	current := r.rooms[eventID]
+	if current != nil {
+		current = current.Clone()
+	}
+	room, err := fn(current)
+	if err != nil {
+		return err
+	}
+
+	if room == nil {
+		delete(r.rooms, eventID)
+	} else {
+		r.rooms[eventID] = room
+	}
+	return nil
+}
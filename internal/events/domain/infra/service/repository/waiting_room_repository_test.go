@@ -0,0 +1,49 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service/repository"
+)
+
+func TestWaitingRoomUpdateDiscardsFailedChanges(t *testing.T) {
+	ctx := context.Background()
+	rooms := repository.NewMemoryWaitingRoomRepository()
+	now := time.Now()
+
+	err := rooms.Update(ctx, "event-1", func(*domain.WaitingRoom) (*domain.WaitingRoom, error) {
+		// Paused so joining buyers stay in the queue
+		room, err := domain.NewWaitingRoom("event-1", 1, time.Minute, now)
+		if err != nil {
+			return nil, err
+		}
+		room.Pause(now)
+		_, err = room.Join("buyer-1", now)
+		return room, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("failed")
+	err = rooms.Update(ctx, "event-1", func(room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+		if _, err := room.Join("buyer-2", now); err != nil {
+			t.Fatal(err)
+		}
+		return room, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("got %v, want %v", err, failure)
+	}
+
+	rooms.Update(ctx, "event-1", func(room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+		if waiting := room.Waiting(); waiting != 1 {
+			t.Errorf("%d buyers waiting, want only the one of the successful update", waiting)
+		}
+		return room, nil
+	})
+}
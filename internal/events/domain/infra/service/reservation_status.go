@@ -0,0 +1,79 @@
+package service
+
+import "strings"
+
+// ReservationStatus is the outcome of one spot in a partner reservation,
+// normalized from whatever the partner calls it
+type ReservationStatus string
+
+const (
+	// ReservationStatusConfirmed means the partner sold the spot
+	ReservationStatusConfirmed ReservationStatus = "confirmed"
+	// ReservationStatusPending means the partner has not decided yet, we do not
+	// issue tickets for it
+	ReservationStatusPending ReservationStatus = "pending"
+	// ReservationStatusRejected means the partner refused the spot, e.g. it was already sold
+	ReservationStatusRejected ReservationStatus = "rejected"
+)
+
+func IsValidReservationStatus(status ReservationStatus) bool {
+	switch status {
+	case ReservationStatusConfirmed, ReservationStatusPending, ReservationStatusRejected:
+		return true
+	}
+	return false
+}
+
+// StatusVocabulary maps partner status values to our statuses
+type StatusVocabulary map[string]ReservationStatus
+
+// DefaultStatuses covers the English values most partners use
+var DefaultStatuses = StatusVocabulary{
+	"confirmed":   ReservationStatusConfirmed,
+	"reserved":    ReservationStatusConfirmed,
+	"success":     ReservationStatusConfirmed,
+	"pending":     ReservationStatusPending,
+	"processing":  ReservationStatusPending,
+	"rejected":    ReservationStatusRejected,
+	"failed":      ReservationStatusRejected,
+	"sold":        ReservationStatusRejected,
+	"unavailable": ReservationStatusRejected,
+}
+
+// Partner2Statuses is the Portuguese vocabulary used by Partner2
+var Partner2Statuses = StatusVocabulary{
+	"reservado":    ReservationStatusConfirmed,
+	"confirmado":   ReservationStatusConfirmed,
+	"pendente":     ReservationStatusPending,
+	"processando":  ReservationStatusPending,
+	"recusado":     ReservationStatusRejected,
+	"vendido":      ReservationStatusRejected,
+	"indisponivel": ReservationStatusRejected,
+}
+
+// FromPartner normalizes a partner status, ignoring case. Values the
+// vocabulary does not know are looked up in DefaultStatuses.
+func (v StatusVocabulary) FromPartner(word string) (ReservationStatus, error) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if status, ok := v[word]; ok {
+		return status, nil
+	}
+	if status, ok := DefaultStatuses[word]; ok {
+		return status, nil
+	}
+	return "", &PartnerContractError{Reason: "unknown reservation status " + word}
+}
+
+// reservationTicketType normalizes the ticket type of a reservation. Partners
+// often leave it out of spots they did not confirm, those keep the requested one.
+func reservationTicketType(vocabulary TicketTypeVocabulary, word string, status ReservationStatus, requested string) (string, error) {
+	if word == "" && status != ReservationStatusConfirmed {
+		return requested, nil
+	}
+
+	ticketType, err := vocabulary.FromPartner(word)
+	if err != nil {
+		return "", err
+	}
+	return string(ticketType), nil
+}
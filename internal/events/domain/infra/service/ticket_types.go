@@ -0,0 +1,39 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+)
+
+// TicketTypeVocabulary maps our ticket types to the words a partner uses for
+// them, types left out are sent and expected as they are
+type TicketTypeVocabulary map[domain.TicketType]string
+
+// Partner2TicketTypes is the Portuguese vocabulary used by Partner2
+var Partner2TicketTypes = TicketTypeVocabulary{
+	domain.TicketTypeHalf: "meia",
+	domain.TicketTypeFull: "inteira",
+}
+
+func (v TicketTypeVocabulary) ToPartner(ticketType string) string {
+	if word, ok := v[domain.TicketType(ticketType)]; ok {
+		return word
+	}
+	return ticketType
+}
+
+// FromPartner normalizes a partner ticket type, ignoring case
+func (v TicketTypeVocabulary) FromPartner(word string) (domain.TicketType, error) {
+	for ticketType, w := range v {
+		if strings.EqualFold(w, word) {
+			return ticketType, nil
+		}
+	}
+
+	ticketType := domain.TicketType(strings.ToLower(word))
+	if !domain.IsValidTicketType(ticketType) {
+		return "", &PartnerContractError{Reason: "unknown ticket type " + word}
+	}
+	return ticketType, nil
+}
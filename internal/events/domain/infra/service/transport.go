@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// ErrPartnerUnavailable is returned without calling the partner while its
+// circuit breaker is open
+var ErrPartnerUnavailable = errors.New("partner unavailable")
+
+type PartnerUnavailableError struct {
+	Partner string
+	// RetryAfter is how long until the breaker lets a trial request through
+	RetryAfter time.Duration
+}
+
+func (e *PartnerUnavailableError) Error() string {
+	return fmt.Sprintf("partner %s unavailable, retry after %s", e.Partner, e.RetryAfter.Round(time.Second))
+}
+
+func (e *PartnerUnavailableError) Is(target error) bool {
+	return target == ErrPartnerUnavailable
+}
+
+type TransportConfig struct {
+	// Timeout bounds a single attempt, including reading the response body
+	Timeout time.Duration
+	// MaxRetries is the number of attempts after the first one, for requests that are safe to repeat
+	MaxRetries  int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// BreakerThreshold consecutive failures open the breaker for BreakerCooldown
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		Timeout:          10 * time.Second,
+		MaxRetries:       2,
+		BackoffBase:      100 * time.Millisecond,
+		BackoffMax:       2 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// PartnerTransport sends requests to one partner. It must be shared by every
+// call to that partner so the circuit breaker sees all of them.
+type PartnerTransport struct {
+	name    string
+	config  TransportConfig
+	client  *http.Client
+	breaker *circuitBreaker
+	// dedupeHeader makes requests carrying it safe to retry
+	dedupeHeader string
+}
+
+type PartnerTransportOption func(t *PartnerTransport)
+
+// WithDedupeHeader retries requests that carry header, for partners that
+// apply a request sent again with the same value once
+func WithDedupeHeader(header string) PartnerTransportOption {
+	return func(t *PartnerTransport) {
+		t.dedupeHeader = header
+	}
+}
+
+func NewPartnerTransport(name string, config TransportConfig, opts ...PartnerTransportOption) *PartnerTransport {
+	t := &PartnerTransport{
+		name:    name,
+		config:  config,
+		client:  &http.Client{Timeout: config.Timeout},
+		breaker: &circuitBreaker{threshold: config.BreakerThreshold, cooldown: config.BreakerCooldown},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Do sends the request, retrying with backoff when doing so cannot apply it twice.
+// Any response returned has a 2xx-4xx status, 5xx responses are turned into errors.
+func (t *PartnerTransport) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	// Lets the partner correlate its logs and traces with ours
+	if id := logging.RequestID(ctx); id != "" && req.Header.Get(logging.RequestIDHeader) == "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	var lastErr error
+	for attempt := 0; attempt <= t.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := t.sleep(ctx, attempt); err != nil {
+				return nil, errors.Join(lastErr, err)
+			}
+		}
+
+		if retryAfter, ok := t.breaker.allow(time.Now()); !ok {
+			slog.WarnContext(ctx, "partner circuit breaker open", "partner", t.name, "retry_after", retryAfter.String())
+			return nil, &PartnerUnavailableError{Partner: t.name, RetryAfter: retryAfter}
+		}
+
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			t.breaker.abandon()
+			return nil, err
+		}
+
+		start := time.Now()
+		response, err := t.client.Do(attemptReq)
+		if err == nil && response.StatusCode < http.StatusInternalServerError {
+			t.breaker.success()
+			slog.DebugContext(ctx, "partner request",
+				"partner", t.name,
+				"method", req.Method,
+				"url", req.URL.Redacted(),
+				"status", response.StatusCode,
+				"attempt", attempt+1,
+				"latency_ms", time.Since(start).Milliseconds(),
+			)
+			return response, nil
+		}
+
+		// A caller giving up says nothing about the partner health
+		if ctx.Err() != nil {
+			t.breaker.abandon()
+			if response != nil {
+				response.Body.Close()
+			}
+			return nil, errors.Join(ErrPartnerRequestFailed, ctx.Err())
+		}
+
+		t.breaker.failure(time.Now())
+		if err != nil {
+			lastErr = fmt.Errorf("%w: %w", ErrPartnerRequestFailed, err)
+		} else {
+			response.Body.Close()
+			lastErr = &PartnerStatusError{StatusCode: response.StatusCode}
+		}
+		slog.WarnContext(ctx, "partner request failed",
+			"partner", t.name,
+			"method", req.Method,
+			"url", req.URL.Redacted(),
+			"attempt", attempt+1,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"error", lastErr,
+		)
+
+		if !t.retryable(req, err) {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+// retryable tells whether repeating req cannot sell or cancel twice: either
+// the method is idempotent, the partner is known to deduplicate it by key, or
+// it never left this host
+func (t *PartnerTransport) retryable(req *http.Request, err error) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+
+	if t.dedupeHeader != "" && req.Header.Get(t.dedupeHeader) != "" {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.GetBody == nil {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+// sleep waits an exponential backoff with full jitter before the given attempt
+func (t *PartnerTransport) sleep(ctx context.Context, attempt int) error {
+	backoff := t.config.BackoffBase << (attempt - 1)
+	if backoff <= 0 || backoff > t.config.BackoffMax {
+		backoff = t.config.BackoffMax
+	}
+	if backoff > 0 {
+		backoff = rand.N(backoff) + 1
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// circuitBreaker opens after threshold consecutive failures. Once the cooldown
+// is over a single trial request is let through, closing the breaker again if
+// it succeeds.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func (b *circuitBreaker) allow(now time.Time) (time.Duration, bool) {
+	if b.threshold <= 0 {
+		return 0, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return 0, true
+	}
+
+	if now.Before(b.openUntil) {
+		return b.openUntil.Sub(now), false
+	}
+	if b.trial {
+		return b.cooldown, false
+	}
+
+	b.trial = true
+	return 0, true
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+}
+
+// abandon settles an attempt that says nothing about the partner, so a
+// half-open breaker lets the next trial through
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+func (b *circuitBreaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.failures >= b.threshold {
+		b.openUntil = now.Add(b.cooldown)
+	}
+}
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// partnerFactory decorates every partner it creates
+type partnerFactory struct {
+	next service.PartnerFactory
+}
+
+// InstrumentPartnerFactory records a span for every call to the partners
+// created by factory. The partner transport sends the span context along.
+func InstrumentPartnerFactory(factory service.PartnerFactory) service.PartnerFactory {
+	return &partnerFactory{next: factory}
+}
+
+func (f *partnerFactory) CreatePartner(partnerID int) (service.Partner, error) {
+	partner, err := f.next.CreatePartner(partnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tracedPartner{next: partner, id: partnerID}, nil
+}
+
+type tracedPartner struct {
+	next service.Partner
+	id   int
+}
+
+func (p *tracedPartner) MakeReservation(ctx context.Context, request *service.ReservationRequest) (responses []service.ReservationResponse, err error) {
+	ctx, span := Tracer().Start(ctx, "partner.MakeReservation",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("partner.id", strconv.Itoa(p.id)),
+			attribute.String("event.id", request.EventID),
+			attribute.Int("spots", len(request.Spots)),
+		),
+	)
+	defer func() { End(span, err) }()
+
+	return p.next.MakeReservation(ctx, request)
+}
+
+func (p *tracedPartner) CancelReservation(ctx context.Context, request *service.CancelReservationRequest) (err error) {
+	ctx, span := Tracer().Start(ctx, "partner.CancelReservation",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("partner.id", strconv.Itoa(p.id)),
+			attribute.String("event.id", request.EventID),
+			attribute.Int("spots", len(request.Spots)),
+		),
+	)
+	defer func() { End(span, err) }()
+
+	return p.next.CancelReservation(ctx, request)
+}
+
+func (p *tracedPartner) CheckHealth(ctx context.Context) error {
+	return service.CheckPartnerHealth(ctx, p.next)
+}
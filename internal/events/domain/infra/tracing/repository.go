@@ -0,0 +1,131 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type eventRepository struct {
+	next domain.EventRepository
+}
+
+// InstrumentEventRepository records a span for every repository operation,
+// the ones run inside a transaction become children of its span
+func InstrumentEventRepository(repo domain.EventRepository) domain.EventRepository {
+	return &eventRepository{next: repo}
+}
+
+func start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "repository."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+func (r *eventRepository) ListEvents(ctx context.Context, filter domain.EventFilter) (page *domain.EventPage, err error) {
+	ctx, span := start(ctx, "ListEvents")
+	defer func() { End(span, err) }()
+	return r.next.ListEvents(ctx, filter)
+}
+
+func (r *eventRepository) FindEventById(ctx context.Context, eventID string) (event *domain.Event, err error) {
+	ctx, span := start(ctx, "FindEventById", attribute.String("event.id", eventID))
+	defer func() { End(span, err) }()
+	return r.next.FindEventById(ctx, eventID)
+}
+
+func (r *eventRepository) ListPartnerIDs(ctx context.Context) (ids []int, err error) {
+	ctx, span := start(ctx, "ListPartnerIDs")
+	defer func() { End(span, err) }()
+	return r.next.ListPartnerIDs(ctx)
+}
+
+func (r *eventRepository) FindSpotsByEventID(ctx context.Context, eventID string) (spots []*domain.Spot, err error) {
+	ctx, span := start(ctx, "FindSpotsByEventID", attribute.String("event.id", eventID))
+	defer func() { End(span, err) }()
+	return r.next.FindSpotsByEventID(ctx, eventID)
+}
+
+func (r *eventRepository) FindSpotByName(ctx context.Context, eventID, spotName string) (spot *domain.Spot, err error) {
+	ctx, span := start(ctx, "FindSpotByName", attribute.String("event.id", eventID), attribute.String("spot.name", spotName))
+	defer func() { End(span, err) }()
+	return r.next.FindSpotByName(ctx, eventID, spotName)
+}
+
+func (r *eventRepository) CreateEvent(ctx context.Context, event *domain.Event) (err error) {
+	ctx, span := start(ctx, "CreateEvent", attribute.String("event.id", event.ID))
+	defer func() { End(span, err) }()
+	return r.next.CreateEvent(ctx, event)
+}
+
+func (r *eventRepository) UpdateEvent(ctx context.Context, event *domain.Event) (err error) {
+	ctx, span := start(ctx, "UpdateEvent", attribute.String("event.id", event.ID))
+	defer func() { End(span, err) }()
+	return r.next.UpdateEvent(ctx, event)
+}
+
+func (r *eventRepository) DeleteEvent(ctx context.Context, eventID string) (err error) {
+	ctx, span := start(ctx, "DeleteEvent", attribute.String("event.id", eventID))
+	defer func() { End(span, err) }()
+	return r.next.DeleteEvent(ctx, eventID)
+}
+
+func (r *eventRepository) CreateSpot(ctx context.Context, spot *domain.Spot) (err error) {
+	ctx, span := start(ctx, "CreateSpot", attribute.String("spot.id", spot.ID))
+	defer func() { End(span, err) }()
+	return r.next.CreateSpot(ctx, spot)
+}
+
+func (r *eventRepository) CreateTicket(ctx context.Context, ticket *domain.Ticket) (err error) {
+	ctx, span := start(ctx, "CreateTicket", attribute.String("ticket.id", ticket.ID))
+	defer func() { End(span, err) }()
+	return r.next.CreateTicket(ctx, ticket)
+}
+
+func (r *eventRepository) ReserveSpot(ctx context.Context, spotID, ticketID, holdID string) (err error) {
+	ctx, span := start(ctx, "ReserveSpot", attribute.String("spot.id", spotID))
+	defer func() { End(span, err) }()
+	return r.next.ReserveSpot(ctx, spotID, ticketID, holdID)
+}
+
+func (r *eventRepository) CreateHold(ctx context.Context, hold *domain.Hold) (err error) {
+	ctx, span := start(ctx, "CreateHold", attribute.String("hold.id", hold.ID))
+	defer func() { End(span, err) }()
+	return r.next.CreateHold(ctx, hold)
+}
+
+func (r *eventRepository) FindHoldByID(ctx context.Context, holdID string) (hold *domain.Hold, err error) {
+	ctx, span := start(ctx, "FindHoldByID", attribute.String("hold.id", holdID))
+	defer func() { End(span, err) }()
+	return r.next.FindHoldByID(ctx, holdID)
+}
+
+func (r *eventRepository) DeleteHold(ctx context.Context, holdID string) (err error) {
+	ctx, span := start(ctx, "DeleteHold", attribute.String("hold.id", holdID))
+	defer func() { End(span, err) }()
+	return r.next.DeleteHold(ctx, holdID)
+}
+
+func (r *eventRepository) HoldSpot(ctx context.Context, spotID, holdID string, until time.Time) (err error) {
+	ctx, span := start(ctx, "HoldSpot", attribute.String("spot.id", spotID), attribute.String("hold.id", holdID))
+	defer func() { End(span, err) }()
+	return r.next.HoldSpot(ctx, spotID, holdID, until)
+}
+
+func (r *eventRepository) ReleaseExpiredHolds(ctx context.Context, now time.Time) (released int, err error) {
+	ctx, span := start(ctx, "ReleaseExpiredHolds")
+	defer func() { End(span, err) }()
+	return r.next.ReleaseExpiredHolds(ctx, now)
+}
+
+func (r *eventRepository) Transaction(ctx context.Context, fn func(ctx context.Context, repo domain.EventRepository) error) (err error) {
+	ctx, span := start(ctx, "Transaction")
+	defer func() { End(span, err) }()
+	return r.next.Transaction(ctx, func(ctx context.Context, repo domain.EventRepository) error {
+		return fn(ctx, &eventRepository{next: repo})
+	})
+}
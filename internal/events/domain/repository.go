@@ -1,12 +1,38 @@
 package domain
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrAlreadyExists is returned when creating an event, spot or ticket whose ID
+// is taken, or a spot whose name is taken in its event
+var ErrAlreadyExists = errors.New("already exists")
+
 type EventRepository interface {
-	ListEvents() ([]*Event, error)
-	FindEventById(eventID string) (*Event, error)
-	FindSpotsByEventID(eventID string) ([]*Spot, error)
-	FindSpotByName(eventID, spotName string) (*Spot, error)
-	// CreateEvent(event *Event) error
-	CreateSpot(spot *Spot) error
-	CreateTicket(ticket *Ticket) error
-	ReserveSpot(spotID, ticketID string) error
+	ListEvents(ctx context.Context, filter EventFilter) (*EventPage, error)
+	FindEventById(ctx context.Context, eventID string) (*Event, error)
+	// ListPartnerIDs returns every partner ID referenced by an event
+	ListPartnerIDs(ctx context.Context) ([]int, error)
+	FindSpotsByEventID(ctx context.Context, eventID string) ([]*Spot, error)
+	FindSpotByName(ctx context.Context, eventID, spotName string) (*Spot, error)
+	CreateEvent(ctx context.Context, event *Event) error
+	UpdateEvent(ctx context.Context, event *Event) error
+	DeleteEvent(ctx context.Context, eventID string) error
+	CreateSpot(ctx context.Context, spot *Spot) error
+	CreateTicket(ctx context.Context, ticket *Ticket) error
+	// ReserveSpot sells a spot that is available, held by holdID or whose hold expired
+	ReserveSpot(ctx context.Context, spotID, ticketID, holdID string) error
+	CreateHold(ctx context.Context, hold *Hold) error
+	FindHoldByID(ctx context.Context, holdID string) (*Hold, error)
+	DeleteHold(ctx context.Context, holdID string) error
+	// HoldSpot locks a spot that is available or whose previous hold expired
+	HoldSpot(ctx context.Context, spotID, holdID string, until time.Time) error
+	// ReleaseExpiredHolds makes spots held past now available again and returns how many were released
+	ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error)
+	// Transaction runs fn against a repository bound to a single unit of work.
+	// Everything fn writes is committed when it returns nil and rolled back
+	// otherwise. fn must call repo with the ctx it is given.
+	Transaction(ctx context.Context, fn func(ctx context.Context, repo EventRepository) error) error
 }
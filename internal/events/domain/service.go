@@ -5,10 +5,19 @@ import (
 	"fmt"
 )
 
+type SpotService interface {
+	GenerateSpots(event *Event, quantity int) error
+}
+
 type spotService struct{}
 
+// MaxSpots is how many spots GenerateSpots can name, A1 to Z10
+const MaxSpots = 26 * 10
+
 var (
-	ErrInvalidQuantity = errors.New("invalid quantity")
+	ErrInvalidQuantity     = errors.New("invalid quantity")
+	ErrTooManySpots        = fmt.Errorf("at most %d spots can be generated", MaxSpots)
+	ErrSpotsExceedCapacity = errors.New("spots exceed the event capacity")
 )
 
 func NewSpotService() *spotService {
@@ -19,9 +28,15 @@ func (s *spotService) GenerateSpots(event *Event, quantity int) error {
 	if quantity < 1 {
 		return ErrInvalidQuantity
 	}
+	if quantity > MaxSpots {
+		return ErrTooManySpots
+	}
+	if len(event.Spots)+quantity > event.Capacity {
+		return ErrSpotsExceedCapacity
+	}
 
 	for i := range quantity {
-		spotName := fmt.Sprintf("%c%d", 'A'+i/10, i&10+1)
+		spotName := fmt.Sprintf("%c%d", 'A'+i/10, i%10+1)
 		spot, err := NewSpot(event, spotName)
 		if err != nil {
 			return err
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -19,15 +20,18 @@ type SpotStatus string
 
 const (
 	SpotStatusAvailable SpotStatus = "available"
+	SpotStatusReserved  SpotStatus = "reserved"
 	SpotStatusSold      SpotStatus = "sold"
 )
 
 type Spot struct {
-	ID       string
-	EventID  string
-	Name     string
-	Status   SpotStatus
-	TicketID string
+	ID        string
+	EventID   string
+	Name      string
+	Status    SpotStatus
+	TicketID  string
+	HoldID    string
+	HeldUntil time.Time
 }
 
 func (s *Spot) Validate() error {
@@ -65,13 +69,38 @@ func NewSpot(event *Event, name string) (*Spot, error) {
 	return spot, nil
 }
 
-func (s *Spot) Reserve(ticketID string) error {
+// IsHeld reports whether the spot is locked by a hold that has not expired yet
+func (s *Spot) IsHeld(now time.Time) bool {
+	return s.Status == SpotStatusReserved && now.Before(s.HeldUntil)
+}
+
+func (s *Spot) Hold(holdID string, until time.Time) error {
+	if s.Status == SpotStatusSold || s.IsHeld(time.Now()) {
+		return ErrSpotAlreadyReserved
+	}
+
+	s.Status = SpotStatusReserved
+	s.HoldID = holdID
+	s.HeldUntil = until
+
+	return nil
+}
+
+// Reserve sells the spot. A spot held by someone else can only be sold through
+// its hold, holdID is empty when the buyer has no hold.
+func (s *Spot) Reserve(ticketID string, holdID string) error {
 	if s.Status == SpotStatusSold {
 		return ErrSpotAlreadyReserved
 	}
 
+	if s.IsHeld(time.Now()) && s.HoldID != holdID {
+		return ErrSpotAlreadyReserved
+	}
+
 	s.Status = SpotStatusSold
 	s.TicketID = ticketID
+	s.HoldID = ""
+	s.HeldUntil = time.Time{}
 
 	return nil
 }
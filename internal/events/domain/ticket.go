@@ -2,6 +2,7 @@ package domain
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 )
@@ -9,9 +10,23 @@ import (
 type TicketType string
 
 var (
-	ErrTicketPriceZero = errors.New("ticket price cannot be zero")
+	ErrTicketPriceZero   = errors.New("ticket price cannot be zero")
+	ErrInvalidTicketType = errors.New("invalid ticket type")
 )
 
+// InvalidTicketTypeError reports the rejected ticket type, it matches ErrInvalidTicketType
+type InvalidTicketTypeError struct {
+	TicketType TicketType
+}
+
+func (e *InvalidTicketTypeError) Error() string {
+	return fmt.Sprintf("invalid ticket type: %q", string(e.TicketType))
+}
+
+func (e *InvalidTicketTypeError) Is(target error) bool {
+	return target == ErrInvalidTicketType
+}
+
 const (
 	TicketTypeHalf TicketType = "half"
 	TicketTypeFull TicketType = "full"
@@ -46,7 +61,7 @@ func (t *Ticket) Validate() error {
 
 func NewTicket(event *Event, spot *Spot, ticketType TicketType) (*Ticket, error) {
 	if !IsValidTicketType(ticketType) {
-		return nil, errors.New("invalid ticket type")
+		return nil, &InvalidTicketTypeError{TicketType: ticketType}
 	}
 
 	ticket := &Ticket{
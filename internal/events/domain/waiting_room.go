@@ -0,0 +1,357 @@
+package domain
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWaitingRoomNotFound        = errors.New("Event has no waiting room")
+	ErrWaitingRoomNotOpen         = errors.New("Waiting room is not accepting buyers")
+	ErrWaitingRoomSettingsInvalid = errors.New("Waiting room admission rate and window must be greater than zero")
+	ErrInvalidWaitingRoomAction   = errors.New("Unknown waiting room action")
+	ErrQueueTokenRequired         = errors.New("Event is selling through a waiting room, a queue token is required")
+	ErrQueueTokenNotFound         = errors.New("Queue token not found")
+	ErrQueueTokenNotAdmitted      = errors.New("Queue token has not been admitted yet")
+	ErrQueueTokenExpired          = errors.New("Queue token expired or was already used")
+	ErrQueueTokenInCheckout       = errors.New("A checkout with this queue token is still being processed")
+)
+
+type WaitingRoomStatus string
+
+const (
+	// WaitingRoomOpen queues arriving buyers and admits them at the admission rate
+	WaitingRoomOpen WaitingRoomStatus = "open"
+	// WaitingRoomPaused keeps queueing buyers but admits nobody
+	WaitingRoomPaused WaitingRoomStatus = "paused"
+	// WaitingRoomDraining turns arriving buyers away and admits the queued
+	// ones, the room closes once everyone admitted is done
+	WaitingRoomDraining WaitingRoomStatus = "draining"
+)
+
+type QueueTokenStatus string
+
+const (
+	QueueTokenWaiting  QueueTokenStatus = "waiting"
+	QueueTokenAdmitted QueueTokenStatus = "admitted"
+	// QueueTokenInCheckout is an admitted token whose checkout is running
+	QueueTokenInCheckout QueueTokenStatus = "in_checkout"
+	QueueTokenUsed       QueueTokenStatus = "used"
+	QueueTokenExpired    QueueTokenStatus = "expired"
+)
+
+// QueueToken is the place of a buyer in a waiting room
+type QueueToken struct {
+	Token   string
+	EventID string
+	// Owner is the authenticated buyer who joined, empty without authentication.
+	// Only the owner can use the token.
+	Owner  string
+	Status QueueTokenStatus
+	// Position is 1 for the next buyer admitted, 0 once admitted
+	Position   int
+	JoinedAt   time.Time
+	AdmittedAt time.Time
+	// ExpiresAt ends the admission, the checkout must start before it
+	ExpiresAt time.Time
+
+	seq        int
+	finishedAt time.Time
+}
+
+// WaitingRoom queues the buyers of a high-demand event and admits them to
+// checkout at AdmissionsPerMinute. Admissions are computed from the elapsed
+// time whenever the room is used, so Advance must run before reading it.
+type WaitingRoom struct {
+	EventID             string
+	Status              WaitingRoomStatus
+	AdmissionsPerMinute int
+	// AdmissionWindow is how long an admitted buyer has to check out
+	AdmissionWindow time.Duration
+
+	tokens map[string]*QueueToken
+	// owners has the live token of each owner, joining again keeps the place
+	owners map[string]*QueueToken
+	// queue holds the waiting tokens in arrival order
+	queue []*QueueToken
+	// admissions holds the admitted tokens by expiry, finished the used and
+	// expired ones in the order they finished, so Advance only looks at the
+	// tokens whose state changes
+	admissions  admissionHeap
+	finished    []*QueueToken
+	active      int
+	nextSeq     int
+	credit      float64
+	lastAdvance time.Time
+}
+
+func NewWaitingRoom(eventID string, admissionsPerMinute int, admissionWindow time.Duration, now time.Time) (*WaitingRoom, error) {
+	room := &WaitingRoom{
+		EventID:     eventID,
+		Status:      WaitingRoomOpen,
+		tokens:      make(map[string]*QueueToken),
+		owners:      make(map[string]*QueueToken),
+		lastAdvance: now,
+	}
+	if err := room.Open(admissionsPerMinute, admissionWindow, now); err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// Clone returns a deep copy of the room, changing it leaves w untouched
+func (w *WaitingRoom) Clone() *WaitingRoom {
+	clone := *w
+	copies := make(map[*QueueToken]*QueueToken, len(w.tokens))
+	copyOf := func(token *QueueToken) *QueueToken {
+		if c, ok := copies[token]; ok {
+			return c
+		}
+		c := *token
+		copies[token] = &c
+		return &c
+	}
+
+	clone.tokens = make(map[string]*QueueToken, len(w.tokens))
+	for id, token := range w.tokens {
+		clone.tokens[id] = copyOf(token)
+	}
+	clone.owners = make(map[string]*QueueToken, len(w.owners))
+	for owner, token := range w.owners {
+		clone.owners[owner] = copyOf(token)
+	}
+	clone.queue = make([]*QueueToken, len(w.queue))
+	for i, token := range w.queue {
+		clone.queue[i] = copyOf(token)
+	}
+	clone.admissions = make(admissionHeap, len(w.admissions))
+	for i, token := range w.admissions {
+		clone.admissions[i] = copyOf(token)
+	}
+	clone.finished = make([]*QueueToken, len(w.finished))
+	for i, token := range w.finished {
+		clone.finished[i] = copyOf(token)
+	}
+	return &clone
+}
+
+// Open starts admitting again, with new settings
+func (w *WaitingRoom) Open(admissionsPerMinute int, admissionWindow time.Duration, now time.Time) error {
+	if admissionsPerMinute <= 0 || admissionWindow <= 0 {
+		return ErrWaitingRoomSettingsInvalid
+	}
+
+	w.Advance(now)
+	w.Status = WaitingRoomOpen
+	w.AdmissionsPerMinute = admissionsPerMinute
+	w.AdmissionWindow = admissionWindow
+	return nil
+}
+
+func (w *WaitingRoom) Pause(now time.Time) {
+	w.Advance(now)
+	w.Status = WaitingRoomPaused
+}
+
+func (w *WaitingRoom) Drain(now time.Time) {
+	w.Advance(now)
+	w.Status = WaitingRoomDraining
+}
+
+// Drained reports whether a draining room has nobody left to serve
+func (w *WaitingRoom) Drained() bool {
+	return w.Status == WaitingRoomDraining && len(w.queue) == 0 && w.active == 0
+}
+
+// Advance admits the buyers whose turn came since the last call and expires
+// the admissions that were not used in time
+func (w *WaitingRoom) Advance(now time.Time) {
+	elapsed := now.Sub(w.lastAdvance)
+	if elapsed < 0 {
+		return
+	}
+	w.lastAdvance = now
+
+	if w.Status != WaitingRoomPaused {
+		w.credit += elapsed.Minutes() * float64(w.AdmissionsPerMinute)
+		for w.credit >= 1 && len(w.queue) > 0 {
+			token := w.queue[0]
+			w.queue = w.queue[1:]
+			token.Status = QueueTokenAdmitted
+			token.AdmittedAt = now
+			token.ExpiresAt = now.Add(w.AdmissionWindow)
+			heap.Push(&w.admissions, token)
+			w.active++
+			w.credit--
+		}
+		// An empty queue does not bank admissions for a later rush
+		if len(w.queue) == 0 {
+			w.credit = math.Min(w.credit, 1)
+		}
+	}
+
+	for len(w.admissions) > 0 && !now.Before(w.admissions[0].ExpiresAt) {
+		// Tokens in checkout are settled by Release or Consume
+		if token := heap.Pop(&w.admissions).(*QueueToken); token.Status == QueueTokenAdmitted {
+			w.finish(token, QueueTokenExpired, now)
+		}
+	}
+
+	// Keep finished tokens for a window so polls see what happened
+	for len(w.finished) > 0 && now.Sub(w.finished[0].finishedAt) > w.AdmissionWindow {
+		delete(w.tokens, w.finished[0].Token)
+		w.finished = w.finished[1:]
+	}
+}
+
+func (w *WaitingRoom) finish(token *QueueToken, status QueueTokenStatus, now time.Time) {
+	token.Status = status
+	token.finishedAt = now
+	w.active--
+	w.finished = append(w.finished, token)
+	if w.owners[token.Owner] == token {
+		delete(w.owners, token.Owner)
+	}
+}
+
+// Join queues a buyer, or returns the token they already have. Without
+// authentication there is no owner to recognize and every call queues again.
+func (w *WaitingRoom) Join(owner string, now time.Time) (*QueueToken, error) {
+	w.Advance(now)
+	if token, ok := w.owners[owner]; ok && owner != "" {
+		return w.Token(token.Token, owner)
+	}
+	if w.Status == WaitingRoomDraining {
+		return nil, ErrWaitingRoomNotOpen
+	}
+
+	w.nextSeq++
+	token := &QueueToken{
+		Token:    uuid.New().String(),
+		EventID:  w.EventID,
+		Owner:    owner,
+		Status:   QueueTokenWaiting,
+		JoinedAt: now,
+		seq:      w.nextSeq,
+	}
+	w.tokens[token.Token] = token
+	w.queue = append(w.queue, token)
+	if owner != "" {
+		w.owners[owner] = token
+	}
+
+	// Admit right away when there is room for it
+	w.Advance(now)
+	return w.Token(token.Token, owner)
+}
+
+// Token returns a copy of a token with its current position. Tokens of
+// another owner are reported as not found.
+func (w *WaitingRoom) Token(id, owner string) (*QueueToken, error) {
+	token, ok := w.tokens[id]
+	if !ok || token.Owner != owner {
+		return nil, ErrQueueTokenNotFound
+	}
+
+	result := *token
+	if token.Status == QueueTokenWaiting && len(w.queue) > 0 {
+		result.Position = token.seq - w.queue[0].seq + 1
+	}
+	return &result, nil
+}
+
+// EstimatedWait is how long a buyer at position waits at the current rate,
+// zero when the room is paused and nobody can tell
+func (w *WaitingRoom) EstimatedWait(position int) time.Duration {
+	if position <= 0 || w.Status == WaitingRoomPaused {
+		return 0
+	}
+	return time.Duration(float64(position) / float64(w.AdmissionsPerMinute) * float64(time.Minute))
+}
+
+// CheckAdmitted makes sure a token lets its owner buy now
+func (w *WaitingRoom) CheckAdmitted(id, owner string) error {
+	token, ok := w.tokens[id]
+	if !ok || token.Owner != owner {
+		return ErrQueueTokenNotFound
+	}
+
+	switch token.Status {
+	case QueueTokenAdmitted:
+		return nil
+	case QueueTokenWaiting:
+		return ErrQueueTokenNotAdmitted
+	case QueueTokenInCheckout:
+		return ErrQueueTokenInCheckout
+	default:
+		return ErrQueueTokenExpired
+	}
+}
+
+// Claim reserves an admitted token for a checkout, Release gives it back if
+// the checkout fails and Consume uses it up once it succeeds
+func (w *WaitingRoom) Claim(id, owner string) error {
+	if err := w.CheckAdmitted(id, owner); err != nil {
+		return err
+	}
+	w.tokens[id].Status = QueueTokenInCheckout
+	return nil
+}
+
+func (w *WaitingRoom) Release(id string, now time.Time) {
+	token, ok := w.tokens[id]
+	if !ok || token.Status != QueueTokenInCheckout {
+		return
+	}
+	if now.Before(token.ExpiresAt) {
+		token.Status = QueueTokenAdmitted
+	} else {
+		w.finish(token, QueueTokenExpired, now)
+	}
+}
+
+func (w *WaitingRoom) Consume(id string, now time.Time) {
+	if token, ok := w.tokens[id]; ok && token.Status == QueueTokenInCheckout {
+		w.finish(token, QueueTokenUsed, now)
+	}
+}
+
+// Waiting is the number of queued buyers
+func (w *WaitingRoom) Waiting() int {
+	return len(w.queue)
+}
+
+// Admitted is the number of buyers admitted who did not finish their checkout
+func (w *WaitingRoom) Admitted() int {
+	return w.active
+}
+
+// admissionHeap orders admitted tokens by expiry, for container/heap
+type admissionHeap []*QueueToken
+
+func (h admissionHeap) Len() int           { return len(h) }
+func (h admissionHeap) Less(i, j int) bool { return h[i].ExpiresAt.Before(h[j].ExpiresAt) }
+func (h admissionHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *admissionHeap) Push(x any) {
+	*h = append(*h, x.(*QueueToken))
+}
+
+func (h *admissionHeap) Pop() any {
+	old := *h
+	token := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return token
+}
+
+type WaitingRoomRepository interface {
+	// Update gives fn exclusive access to the waiting room of an event, nil
+	// when the event has none. The room fn returns is kept, nil removes it.
+	Update(ctx context.Context, eventID string, fn func(room *WaitingRoom) (*WaitingRoom, error)) error
+}
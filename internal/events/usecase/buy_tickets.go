@@ -1,48 +1,276 @@
 package usecase
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+
 	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
 	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/tracing"
+	"github.com/devfullcycle/imersao18/golang/internal/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Ticket struct
 // ID         string
 type BuyTicketInputDTO struct {
-	EventID    string   `json:"event_id"`
+	EventID string `json:"event_id"`
+	// HoldID buys the spots locked by a hold, Spots is ignored when it is set
+	HoldID     string   `json:"hold_id"`
 	Spots      []string `json:"spot"`
 	TicketType string   `json:"ticket_type"`
 	CardHash   string   `json:"card_hash"`
 	Email      string   `json:"email"`
+	// IdempotencyKey is forwarded to partners that deduplicate reservations
+	IdempotencyKey string `json:"-"`
+	// QueueToken is the admitted waiting room token, for events selling through one
+	QueueToken string `json:"queue_token"`
+	// BuyerID is the authenticated buyer, the owner of QueueToken
+	BuyerID string `json:"-"`
+}
+
+// UnmarshalJSON accepts the spots under "spot" and, like the partner
+// ReservationRequest, under "spots"
+func (dto *BuyTicketInputDTO) UnmarshalJSON(data []byte) error {
+	type input BuyTicketInputDTO
+	var aux struct {
+		input
+		SpotsAlias []string `json:"spots"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*dto = BuyTicketInputDTO(aux.input)
+	dto.Spots = append(dto.Spots, aux.SpotsAlias...)
+	return nil
+}
+
+// Validate checks the input before anything is sent to the partner
+func (dto BuyTicketInputDTO) Validate(maxSpotsPerOrder int) error {
+	validation := &ValidationError{}
+
+	if strings.TrimSpace(dto.EventID) == "" {
+		validation.Add("event_id", "is required")
+	}
+
+	if dto.HoldID == "" {
+		if len(dto.Spots) == 0 {
+			validation.Add("spots", "at least one spot is required")
+		}
+		if dto.Email == "" {
+			validation.Add("email", "is required")
+		}
+	}
+
+	if maxSpotsPerOrder > 0 && len(dto.Spots) > maxSpotsPerOrder {
+		validation.Add("spots", fmt.Sprintf("at most %d spots per order", maxSpotsPerOrder))
+	}
+
+	seen := make(map[string]bool, len(dto.Spots))
+	for _, name := range dto.Spots {
+		if seen[name] {
+			validation.Add("spots", fmt.Sprintf("spot %q is duplicated", name))
+			continue
+		}
+		seen[name] = true
+
+		spot := domain.Spot{Name: name}
+		if err := spot.Validate(); err != nil {
+			validation.Add("spots", fmt.Sprintf("spot %q: %s", name, err))
+		}
+	}
+
+	if dto.Email != "" {
+		if address, err := mail.ParseAddress(dto.Email); err != nil || address.Address != dto.Email {
+			validation.Add("email", "is not a valid email address")
+		}
+	}
+
+	if strings.TrimSpace(dto.CardHash) == "" {
+		validation.Add("card_hash", "is required")
+	}
+
+	if !domain.IsValidTicketType(domain.TicketType(dto.TicketType)) {
+		validation.Add("ticket_type", fmt.Sprintf("must be %q or %q", domain.TicketTypeFull, domain.TicketTypeHalf))
+	}
+
+	return validation.Err()
 }
 
 type BuyTicketsOutputDTO struct {
 	Tickets []TicketDTO `json:"tickets"`
+	// FailedSpots lists the spots the partner did not confirm, only with ReservationPolicyAcceptPartial
+	FailedSpots []FailedSpotDTO `json:"failed_spots,omitempty"`
+	// PartnerID is the partner that sold the tickets
+	PartnerID int `json:"-"`
+}
+
+type FailedSpotDTO struct {
+	Spot   string `json:"spot"`
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// ReservationPolicy decides what a checkout does when the partner confirms
+// only some of the spots
+type ReservationPolicy string
+
+const (
+	// ReservationPolicyAllOrNothing cancels the confirmed spots and fails the checkout
+	ReservationPolicyAllOrNothing ReservationPolicy = "all_or_nothing"
+	// ReservationPolicyAcceptPartial issues tickets for the confirmed spots and
+	// reports the others in BuyTicketsOutputDTO.FailedSpots
+	ReservationPolicyAcceptPartial ReservationPolicy = "accept_partial"
+)
+
+func IsValidReservationPolicy(policy ReservationPolicy) bool {
+	return policy == ReservationPolicyAllOrNothing || policy == ReservationPolicyAcceptPartial
+}
+
+var ErrReservationRejected = errors.New("Partner did not confirm the reservation")
+
+// ReservationRejectedError is returned when no ticket was issued because the
+// partner did not confirm the spots
+type ReservationRejectedError struct {
+	FailedSpots []FailedSpotDTO
+}
+
+func (e *ReservationRejectedError) Error() string {
+	return fmt.Sprintf("partner did not confirm %d spot(s)", len(e.FailedSpots))
+}
+
+func (e *ReservationRejectedError) Is(target error) bool {
+	return target == ErrReservationRejected
+}
+
+// CheckoutError is a failed checkout of an event that exists, it tells the
+// decorators around BuyTicketsUseCase which partner the event sells through
+type CheckoutError struct {
+	PartnerID int
+	Err       error
+}
+
+func (e *CheckoutError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CheckoutError) Unwrap() error {
+	return e.Err
+}
+
+// DefaultMaxSpotsPerOrder is used unless WithMaxSpotsPerOrder says otherwise
+const DefaultMaxSpotsPerOrder = 10
+
+const cancelReservationTimeout = 30 * time.Second
+
+// BuyTickets is implemented by BuyTicketsUseCase and the decorators around it
+type BuyTickets interface {
+	Execute(ctx context.Context, dto BuyTicketInputDTO) (*BuyTicketsOutputDTO, error)
 }
 
 type BuyTicketsUseCase struct {
-	repo           domain.EventRepository
-	partnerFactory service.PartnerFactory
+	repo             domain.EventRepository
+	partnerFactory   service.PartnerFactory
+	maxSpotsPerOrder int
+	policy           ReservationPolicy
 }
 
-func NewBuyTicketsUseCase(repo domain.EventRepository, partnerFactory service.PartnerFactory) *BuyTicketsUseCase {
-	return &BuyTicketsUseCase{
-		repo:           repo,
-		partnerFactory: partnerFactory,
+type BuyTicketsOption func(uc *BuyTicketsUseCase)
+
+// WithMaxSpotsPerOrder limits how many spots a single checkout can buy, zero disables the limit
+func WithMaxSpotsPerOrder(max int) BuyTicketsOption {
+	return func(uc *BuyTicketsUseCase) {
+		uc.maxSpotsPerOrder = max
 	}
 }
 
-func (uc *BuyTicketsUseCase) Execute(dto BuyTicketInputDTO) (*BuyTicketsOutputDTO, error) {
-	event, err := uc.repo.FindEventById(dto.EventID)
+// WithReservationPolicy sets how partially confirmed reservations are handled,
+// ReservationPolicyAllOrNothing by default
+func WithReservationPolicy(policy ReservationPolicy) BuyTicketsOption {
+	return func(uc *BuyTicketsUseCase) {
+		uc.policy = policy
+	}
+}
+
+func NewBuyTicketsUseCase(repo domain.EventRepository, partnerFactory service.PartnerFactory, opts ...BuyTicketsOption) *BuyTicketsUseCase {
+	uc := &BuyTicketsUseCase{
+		repo:             repo,
+		partnerFactory:   partnerFactory,
+		maxSpotsPerOrder: DefaultMaxSpotsPerOrder,
+		policy:           ReservationPolicyAllOrNothing,
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+func (uc *BuyTicketsUseCase) Execute(ctx context.Context, dto BuyTicketInputDTO) (output *BuyTicketsOutputDTO, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.BuyTickets", trace.WithAttributes(
+		attribute.String("event.id", dto.EventID),
+	))
+	start := time.Now()
+	partnerID := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("partner.id", partnerID), attribute.Int("spots", len(dto.Spots)))
+		tracing.End(span, err)
+		logCheckout(ctx, dto, partnerID, time.Since(start), output, err)
+		if err != nil && partnerID != 0 {
+			err = &CheckoutError{PartnerID: partnerID, Err: err}
+		}
+	}()
+
+	if err := dto.Validate(uc.maxSpotsPerOrder); err != nil {
+		return nil, err
+	}
+
+	event, err := uc.repo.FindEventById(ctx, dto.EventID)
 	if err != nil {
 		return nil, err
 	}
+	partnerID = event.PartnerID
+
+	if dto.HoldID != "" {
+		hold, err := uc.repo.FindHoldByID(ctx, dto.HoldID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Another buyer's hold is reported as not found
+		if hold.EventID != event.ID || hold.BuyerID != dto.BuyerID {
+			return nil, domain.ErrHoldNotFound
+		}
+
+		if hold.IsExpired(time.Now()) {
+			return nil, domain.ErrHoldExpired
+		}
+
+		dto.Spots = hold.Spots
+		if dto.Email == "" {
+			dto.Email = hold.Email
+		}
+
+		// The hold may predate the current limits, check what it would buy
+		if err := dto.Validate(uc.maxSpotsPerOrder); err != nil {
+			return nil, err
+		}
+	}
 
 	request := &service.ReservationRequest{
-		EventID:    dto.EventID,
-		Spots:      dto.Spots,
-		TicketType: dto.TicketType,
-		CardHash:   dto.CardHash,
-		Email:      dto.Email,
+		EventID:        dto.EventID,
+		Spots:          dto.Spots,
+		TicketType:     dto.TicketType,
+		CardHash:       dto.CardHash,
+		Email:          dto.Email,
+		IdempotencyKey: dto.IdempotencyKey,
 	}
 
 	partnerService, err := uc.partnerFactory.CreatePartner(event.PartnerID)
@@ -50,35 +278,87 @@ func (uc *BuyTicketsUseCase) Execute(dto BuyTicketInputDTO) (*BuyTicketsOutputDT
 		return nil, err
 	}
 
-	reservationResponse, err := partnerService.MakeReservation(request)
+	reservationResponse, err := partnerService.MakeReservation(ctx, request)
 	if err != nil {
-		return nil, err
+		// Cancel whatever the partner may have sold in an answer we could not read
+		var unsettled []service.ReservationResponse
+		for _, reservation := range reservationResponse {
+			if reservation.Status != service.ReservationStatusRejected {
+				unsettled = append(unsettled, reservation)
+			}
+		}
+		return nil, uc.cancelReservation(ctx, partnerService, dto.EventID, unsettled, err)
 	}
 
-	tickets := make([]domain.Ticket, len(reservationResponse))
-	for i, reservation := range reservationResponse {
-		spot, err := uc.repo.FindSpotByName(event.ID, reservation.Spot)
-		if err != nil {
-			return nil, err
+	if err := service.CheckReservation(request, reservationResponse); err != nil {
+		return nil, uc.cancelReservation(ctx, partnerService, dto.EventID, reservationResponse, err)
+	}
+
+	var confirmed, pending []service.ReservationResponse
+	var failedSpots []FailedSpotDTO
+	for _, reservation := range reservationResponse {
+		switch reservation.Status {
+		case service.ReservationStatusConfirmed:
+			confirmed = append(confirmed, reservation)
+			continue
+		case service.ReservationStatusPending:
+			// We cannot issue a ticket the partner may still refuse
+			pending = append(pending, reservation)
 		}
+		failedSpots = append(failedSpots, newFailedSpotDTO(reservation))
+	}
 
-		ticket, err := domain.NewTicket(event, spot, domain.TicketType(reservation.TicketType))
-		if err != nil {
-			return nil, err
+	if len(failedSpots) > 0 && (uc.policy != ReservationPolicyAcceptPartial || len(confirmed) == 0) {
+		cause := &ReservationRejectedError{FailedSpots: failedSpots}
+		return nil, uc.cancelReservation(ctx, partnerService, dto.EventID, append(confirmed, pending...), cause)
+	}
+
+	if len(pending) > 0 {
+		if err := uc.cancelReservation(ctx, partnerService, dto.EventID, pending, nil); err != nil {
+			return nil, uc.cancelReservation(ctx, partnerService, dto.EventID, confirmed, err)
 		}
+	}
 
-		err = uc.repo.CreateTicket(ticket)
-		if err != nil {
-			return nil, err
+	tickets := make([]domain.Ticket, len(confirmed))
+	err = uc.repo.Transaction(ctx, func(ctx context.Context, repo domain.EventRepository) error {
+		for i, reservation := range confirmed {
+			spot, err := repo.FindSpotByName(ctx, event.ID, reservation.Spot)
+			if err != nil {
+				return err
+			}
+
+			ticket, err := domain.NewTicket(event, spot, domain.TicketType(reservation.TicketType))
+			if err != nil {
+				return err
+			}
+
+			err = spot.Reserve(ticket.ID, dto.HoldID)
+			if err != nil {
+				return err
+			}
+
+			err = repo.CreateTicket(ctx, ticket)
+			if err != nil {
+				return err
+			}
+
+			err = repo.ReserveSpot(ctx, spot.ID, ticket.ID, dto.HoldID)
+			if err != nil {
+				return err
+			}
+
+			tickets[i] = *ticket
 		}
 
-		spot.Reserve(ticket.ID)
-		err = uc.repo.ReserveSpot(spot.ID, ticket.ID)
-		if err != nil {
-			return nil, err
+		if dto.HoldID != "" {
+			return repo.DeleteHold(ctx, dto.HoldID)
 		}
 
-		tickets[i] = *ticket
+		return nil
+	})
+	if err != nil {
+		// The partner already sold the spots, give them back before failing
+		return nil, uc.cancelReservation(ctx, partnerService, dto.EventID, confirmed, err)
 	}
 
 	ticketsDTOs := make([]TicketDTO, len(tickets))
@@ -92,6 +372,92 @@ func (uc *BuyTicketsUseCase) Execute(dto BuyTicketInputDTO) (*BuyTicketsOutputDT
 	}
 
 	return &BuyTicketsOutputDTO{
-		Tickets: ticketsDTOs,
+		Tickets:     ticketsDTOs,
+		FailedSpots: failedSpots,
+		PartnerID:   event.PartnerID,
 	}, nil
 }
+
+// logCheckout records the outcome of a checkout, without the buyer personal data
+func logCheckout(ctx context.Context, dto BuyTicketInputDTO, partnerID int, latency time.Duration, output *BuyTicketsOutputDTO, err error) {
+	level := slog.LevelInfo
+	attrs := []any{
+		"event_id", dto.EventID,
+		"partner_id", partnerID,
+		"spots", len(dto.Spots),
+		"latency_ms", latency.Milliseconds(),
+		logging.Email(dto.Email),
+		logging.CardHash(dto.CardHash),
+	}
+
+	switch {
+	case err == nil && len(output.FailedSpots) > 0:
+		attrs = append(attrs, "outcome", "partial", "tickets", len(output.Tickets), "failed_spots", len(output.FailedSpots))
+	case err == nil:
+		attrs = append(attrs, "outcome", "confirmed", "tickets", len(output.Tickets))
+	case errors.Is(err, ErrInvalidInput):
+		attrs = append(attrs, "outcome", "invalid", "error", err)
+	case errors.Is(err, ErrReservationRejected):
+		level = slog.LevelWarn
+		attrs = append(attrs, "outcome", "rejected", "error", err)
+	default:
+		level = slog.LevelError
+		attrs = append(attrs, "outcome", "failed", "error", err)
+	}
+
+	slog.Log(ctx, level, "checkout", attrs...)
+}
+
+func newFailedSpotDTO(reservation service.ReservationResponse) FailedSpotDTO {
+	reason := reservation.Reason
+	if reason == "" && reservation.Status == service.ReservationStatusPending {
+		reason = "not confirmed by the partner in time"
+	} else if reason == "" {
+		reason = "rejected by the partner"
+	}
+
+	return FailedSpotDTO{
+		Spot:   reservation.Spot,
+		Status: string(reservation.Status),
+		Reason: reason,
+	}
+}
+
+// cancelReservation gives reservations back to the partner and returns cause.
+// A failed cancellation is logged rather than returned, so the response still
+// reflects cause: a local failure must not read as a partner one. Without a
+// cause the cancellation error is returned.
+func (uc *BuyTicketsUseCase) cancelReservation(ctx context.Context, partner service.Partner, eventID string, reservations []service.ReservationResponse, cause error) error {
+	if len(reservations) == 0 {
+		return cause
+	}
+
+	request := &service.CancelReservationRequest{
+		EventID:        eventID,
+		ReservationIDs: make([]string, len(reservations)),
+		Spots:          make([]string, len(reservations)),
+	}
+	for i, reservation := range reservations {
+		request.ReservationIDs[i] = reservation.ID
+		request.Spots[i] = reservation.Spot
+	}
+
+	// The compensation must run even when the buyer went away, ctx may be canceled already
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelReservationTimeout)
+	defer cancel()
+
+	if err := partner.CancelReservation(ctx, request); err != nil {
+		err = fmt.Errorf("cancel partner reservation: %w", err)
+		if cause == nil {
+			return err
+		}
+		slog.ErrorContext(ctx, "partner reservation left uncancelled",
+			"event_id", eventID,
+			"reservation_ids", request.ReservationIDs,
+			"cause", cause,
+			"error", err,
+		)
+	}
+
+	return cause
+}
@@ -0,0 +1,248 @@
+package usecase_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service/repository"
+	"github.com/devfullcycle/imersao18/golang/internal/events/usecase"
+	"github.com/devfullcycle/imersao18/golang/internal/fakepartner"
+	"github.com/google/uuid"
+)
+
+const fakePartnerToken = "secret"
+
+type checkout struct {
+	uc      *usecase.BuyTicketsUseCase
+	repo    domain.EventRepository
+	partner *fakepartner.Server
+	event   *domain.Event
+}
+
+// newCheckout sells an event of 5 spots through a fake partner of kind
+func newCheckout(t *testing.T, kind string, protocol fakepartner.Protocol) *checkout {
+	t.Helper()
+
+	server := fakepartner.NewTestServer(protocol, fakePartnerToken)
+	t.Cleanup(server.Close)
+
+	c := newCheckoutAt(t, kind, server.URL)
+	c.partner = server.Partner
+	return c
+}
+
+// newCheckoutAt sells an event of 5 spots through a partner of kind at baseURL
+func newCheckoutAt(t *testing.T, kind, baseURL string) *checkout {
+	t.Helper()
+
+	config := service.DefaultTransportConfig()
+	config.MaxRetries = 0
+	config.Timeout = 100 * time.Millisecond
+	factory, err := service.NewPartnerFactory(service.DefaultPartnerRegistry(), []service.PartnerDefinition{{
+		ID:          1,
+		Name:        kind,
+		Kind:        kind,
+		BaseURL:     baseURL,
+		Credentials: map[string]string{"token": fakePartnerToken},
+	}}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := repository.NewMemoryEventRepository()
+	event := &domain.Event{
+		ID:           uuid.New().String(),
+		Name:         "Contract",
+		Location:     "Arena",
+		Organization: "Org",
+		Rating:       domain.RatingLivre,
+		Date:         time.Now().Add(24 * time.Hour),
+		Capacity:     5,
+		Price:        100,
+		PartnerID:    1,
+	}
+	if err := domain.NewSpotService().GenerateSpots(event, 5); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := repo.CreateEvent(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+	for i := range event.Spots {
+		if err := repo.CreateSpot(ctx, &event.Spots[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return &checkout{
+		uc:    usecase.NewBuyTicketsUseCase(repo, factory),
+		repo:  repo,
+		event: event,
+	}
+}
+
+func (c *checkout) buy(spots ...string) (*usecase.BuyTicketsOutputDTO, error) {
+	return c.uc.Execute(context.Background(), usecase.BuyTicketInputDTO{
+		EventID:    c.event.ID,
+		Spots:      spots,
+		TicketType: "full",
+		CardHash:   "card",
+		Email:      "buyer@example.com",
+	})
+}
+
+// soldSpots returns the spots of the event sold locally
+func (c *checkout) soldSpots(t *testing.T) []string {
+	t.Helper()
+	spots, err := c.repo.FindSpotsByEventID(context.Background(), c.event.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sold []string
+	for _, spot := range spots {
+		if spot.Status == domain.SpotStatusSold {
+			sold = append(sold, spot.Name)
+		}
+	}
+	return sold
+}
+
+func TestBuyTicketsAgainstFakePartner(t *testing.T) {
+	for _, p := range []struct {
+		kind     string
+		protocol fakepartner.Protocol
+	}{
+		{service.Partner1Kind, fakepartner.Partner1Protocol},
+		{service.Partner2Kind, fakepartner.Partner2Protocol},
+	} {
+		t.Run(p.kind, func(t *testing.T) {
+			t.Run("issues confirmed tickets", func(t *testing.T) {
+				c := newCheckout(t, p.kind, p.protocol)
+
+				output, err := c.buy("A1", "A2")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(output.Tickets) != 2 {
+					t.Errorf("got %d tickets, want 2", len(output.Tickets))
+				}
+				if sold := c.soldSpots(t); len(sold) != 2 {
+					t.Errorf("sold %v locally, want A1 and A2", sold)
+				}
+				if reserved := c.partner.Reservations(c.event.ID); len(reserved) != 2 {
+					t.Errorf("partner reserved %d spots, want 2", len(reserved))
+				}
+			})
+
+			t.Run("cancels the confirmed spots when one is sold", func(t *testing.T) {
+				c := newCheckout(t, p.kind, p.protocol)
+				c.partner.MarkSold(c.event.ID, "A2")
+
+				_, err := c.buy("A1", "A2")
+				var rejected *usecase.ReservationRejectedError
+				if !errors.As(err, &rejected) {
+					t.Fatalf("got %v, want %v", err, usecase.ErrReservationRejected)
+				}
+				if len(rejected.FailedSpots) != 1 || rejected.FailedSpots[0].Spot != "A2" {
+					t.Errorf("got failed spots %+v, want A2", rejected.FailedSpots)
+				}
+				if sold := c.soldSpots(t); len(sold) != 0 {
+					t.Errorf("sold %v locally, want none", sold)
+				}
+				// Only the spot marked sold is left at the partner, A1 was cancelled
+				if reserved := c.partner.Reservations(c.event.ID); len(reserved) != 1 {
+					t.Errorf("partner keeps %d reservations, want only the sold spot", len(reserved))
+				}
+			})
+
+			for _, failure := range []struct {
+				name     string
+				failures fakepartner.Failures
+			}{
+				{"server error", fakepartner.Failures{StatusCode: 503}},
+				{"malformed JSON", fakepartner.Failures{MalformedJSON: true}},
+				{"latency over the timeout", fakepartner.Failures{Latency: 500 * time.Millisecond}},
+			} {
+				t.Run(failure.name, func(t *testing.T) {
+					c := newCheckout(t, p.kind, p.protocol)
+					c.partner.SetFailures(failure.failures)
+
+					_, err := c.buy("A1")
+					if !errors.Is(err, service.ErrPartnerRequestFailed) {
+						t.Errorf("got %v, want %v", err, service.ErrPartnerRequestFailed)
+					}
+					if sold := c.soldSpots(t); len(sold) != 0 {
+						t.Errorf("sold %v locally, want none", sold)
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestBuyTicketsCancelsUnreadableReservations covers a partner that sells the
+// spots but answers with a status we do not know
+func TestBuyTicketsCancelsUnreadableReservations(t *testing.T) {
+	var cancelled []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/reserve"):
+			json.NewEncoder(w).Encode([]map[string]string{
+				{"id": "r1", "spot": "A1", "ticket_kind": "full", "status": "reserved"},
+				{"id": "r2", "spot": "A2", "ticket_kind": "full", "status": "parked"},
+				{"id": "r3", "spot": "A3", "ticket_kind": "full", "status": "rejected"},
+			})
+		case strings.HasSuffix(r.URL.Path, "/cancel"):
+			var body struct {
+				Reservations []string `json:"reservations"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			cancelled = append(cancelled, body.Reservations...)
+		}
+	}))
+	t.Cleanup(server.Close)
+	c := newCheckoutAt(t, service.Partner1Kind, server.URL)
+
+	if _, err := c.buy("A1", "A2", "A3"); err == nil {
+		t.Fatal("got no error for an unknown status")
+	}
+	if strings.Join(cancelled, ",") != "r1,r2" {
+		t.Errorf("cancelled %v, want the confirmed r1 and the unknown r2", cancelled)
+	}
+	if sold := c.soldSpots(t); len(sold) != 0 {
+		t.Errorf("sold %v locally, want none", sold)
+	}
+}
+
+// TestBuyTicketsKeepsLocalFailures covers a checkout failing locally after the
+// partner sold the spots, and a cancellation that fails too
+func TestBuyTicketsKeepsLocalFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/cancel") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]string{
+			{"id": "r1", "spot": "A9", "ticket_kind": "full", "status": "reserved"},
+		})
+	}))
+	t.Cleanup(server.Close)
+	c := newCheckoutAt(t, service.Partner1Kind, server.URL)
+
+	// The event has spots A1 to A5, the partner sells A9 anyway
+	_, err := c.buy("A9")
+	if !errors.Is(err, domain.ErrSpotNotFound) {
+		t.Errorf("got %v, want %v", err, domain.ErrSpotNotFound)
+	}
+	if errors.Is(err, service.ErrPartnerRequestFailed) {
+		t.Errorf("got %v, a local failure reported as a partner one", err)
+	}
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/tracing"
+	"github.com/google/uuid"
+)
+
+type CreateEventInputDTO struct {
+	Name         string  `json:"name"`
+	Location     string  `json:"location"`
+	Organization string  `json:"organization"`
+	Rating       string  `json:"rating"`
+	Date         string  `json:"date"`
+	ImageURL     string  `json:"image_url"`
+	Capacity     int     `json:"capacity"`
+	Price        float64 `json:"price"`
+	PartnerID    int     `json:"partner_id"`
+	// Spots is the number of spots to generate for the event, zero skips generation
+	Spots int `json:"spots"`
+}
+
+type CreateEventOutputDTO struct {
+	Event EventDTO  `json:"event"`
+	Spots []SpotDTO `json:"spots"`
+}
+
+type CreateEventUseCase struct {
+	repo           domain.EventRepository
+	spotService    domain.SpotService
+	partnerFactory service.PartnerFactory
+}
+
+func NewCreateEventUseCase(repo domain.EventRepository, spotService domain.SpotService, partnerFactory service.PartnerFactory) *CreateEventUseCase {
+	return &CreateEventUseCase{
+		repo:           repo,
+		spotService:    spotService,
+		partnerFactory: partnerFactory,
+	}
+}
+
+// checkPartner makes sure tickets of an event can be sold through its partner
+func checkPartner(partnerFactory service.PartnerFactory, partnerID int) error {
+	_, err := partnerFactory.CreatePartner(partnerID)
+	if errors.Is(err, service.ErrPartnerNotFound) {
+		validation := &ValidationError{}
+		validation.Add("partner_id", fmt.Sprintf("no partner is registered with ID %d", partnerID))
+		return validation
+	}
+	return err
+}
+
+func (uc *CreateEventUseCase) Execute(ctx context.Context, input CreateEventInputDTO) (*CreateEventOutputDTO, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.CreateEvent")
+	defer span.End()
+
+	date, err := time.Parse("2006-01-02 15:04:05", input.Date)
+	if err != nil {
+		return nil, domain.ErrEventDateRequired
+	}
+
+	event := &domain.Event{
+		ID:           uuid.New().String(),
+		Name:         input.Name,
+		Location:     input.Location,
+		Organization: input.Organization,
+		Rating:       domain.Rating(input.Rating),
+		Date:         date,
+		ImageURL:     input.ImageURL,
+		Capacity:     input.Capacity,
+		Price:        input.Price,
+		PartnerID:    input.PartnerID,
+	}
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := checkPartner(uc.partnerFactory, event.PartnerID); err != nil {
+		return nil, err
+	}
+
+	if input.Spots > 0 {
+		if err := uc.spotService.GenerateSpots(event, input.Spots); err != nil {
+			return nil, err
+		}
+	}
+
+	err = uc.repo.Transaction(ctx, func(ctx context.Context, repo domain.EventRepository) error {
+		if err := repo.CreateEvent(ctx, event); err != nil {
+			return err
+		}
+
+		for i := range event.Spots {
+			if err := repo.CreateSpot(ctx, &event.Spots[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	spotsDTOs := make([]SpotDTO, len(event.Spots))
+	for i, spot := range event.Spots {
+		spotsDTOs[i] = SpotDTO{
+			ID:      spot.ID,
+			Name:    spot.Name,
+			EventID: spot.EventID,
+			Status:  string(spot.Status),
+		}
+	}
+
+	return &CreateEventOutputDTO{
+		Event: EventDTO{
+			ID:           event.ID,
+			Name:         event.Name,
+			Location:     event.Location,
+			Organization: event.Organization,
+			Rating:       string(event.Rating),
+			Date:         event.Date.Format("2006-01-02 15:04:05"),
+			ImageURL:     event.ImageURL,
+			Capacity:     event.Capacity,
+			Price:        event.Price,
+			PartnerID:    event.PartnerID,
+		},
+		Spots: spotsDTOs,
+	}, nil
+}
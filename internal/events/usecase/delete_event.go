@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/tracing"
+)
+
+type DeleteEventInputDTO struct {
+	ID string
+}
+
+type DeleteEventUseCase struct {
+	repo domain.EventRepository
+}
+
+func NewDeleteEventUseCase(repo domain.EventRepository) *DeleteEventUseCase {
+	return &DeleteEventUseCase{repo: repo}
+}
+
+func (uc *DeleteEventUseCase) Execute(ctx context.Context, input DeleteEventInputDTO) error {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.DeleteEvent")
+	defer span.End()
+
+	if _, err := uc.repo.FindEventById(ctx, input.ID); err != nil {
+		return err
+	}
+
+	return uc.repo.DeleteEvent(ctx, input.ID)
+}
@@ -1,6 +1,11 @@
 package usecase
 
-import "github.com/devfullcycle/imersao18/golang/internal/events/domain"
+import (
+	"context"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/tracing"
+)
 
 type GetEventInputDTO struct {
 	ID string
@@ -27,8 +32,11 @@ func NewGetEventUseCase(repo domain.EventRepository) *GetEventUseCase {
 	return &GetEventUseCase{repo: repo}
 }
 
-func (us *GetEventUseCase) Execute(input GetEventInputDTO) (*GetEventOutputDTO, error) {
-	event, err := us.repo.FindEventById(input.ID)
+func (us *GetEventUseCase) Execute(ctx context.Context, input GetEventInputDTO) (*GetEventOutputDTO, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.GetEvent")
+	defer span.End()
+
+	event, err := us.repo.FindEventById(ctx, input.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/tracing"
+)
+
+type HoldSpotsInputDTO struct {
+	EventID string   `json:"-"`
+	Spots   []string `json:"spots"`
+	Email   string   `json:"email"`
+	// QueueToken is used up by the hold like by a checkout, see
+	// NewWaitingRoomBuyTickets. Only BuyerID can buy the hold.
+	QueueToken string `json:"queue_token"`
+	BuyerID    string `json:"-"`
+}
+
+type HoldSpotsOutputDTO struct {
+	ID        string   `json:"id"`
+	EventID   string   `json:"event_id"`
+	Spots     []string `json:"spots"`
+	ExpiresAt string   `json:"expires_at"`
+}
+
+type HoldSpotsUseCase struct {
+	repo     domain.EventRepository
+	duration time.Duration
+	maxSpots int
+	rooms    domain.WaitingRoomRepository
+}
+
+type HoldSpotsOption func(uc *HoldSpotsUseCase)
+
+// WithHoldMaxSpots limits how many spots a hold locks, it should match
+// WithMaxSpotsPerOrder since the checkout of a hold buys all of them
+func WithHoldMaxSpots(max int) HoldSpotsOption {
+	return func(uc *HoldSpotsUseCase) {
+		uc.maxSpots = max
+	}
+}
+
+// WithHoldWaitingRooms makes holds on an event with a waiting room use up an
+// admitted queue token, so holds cannot skip the queue
+func WithHoldWaitingRooms(rooms domain.WaitingRoomRepository) HoldSpotsOption {
+	return func(uc *HoldSpotsUseCase) {
+		uc.rooms = rooms
+	}
+}
+
+func NewHoldSpotsUseCase(repo domain.EventRepository, duration time.Duration, opts ...HoldSpotsOption) *HoldSpotsUseCase {
+	uc := &HoldSpotsUseCase{
+		repo:     repo,
+		duration: duration,
+		maxSpots: DefaultMaxSpotsPerOrder,
+	}
+	for _, opt := range opts {
+		opt(uc)
+	}
+	return uc
+}
+
+func (uc *HoldSpotsUseCase) Execute(ctx context.Context, input HoldSpotsInputDTO) (output *HoldSpotsOutputDTO, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.HoldSpots")
+	defer span.End()
+
+	event, err := uc.repo.FindEventById(ctx, input.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := domain.NewHold(event, input.Spots, input.Email, uc.duration, uc.maxSpots)
+	if err != nil {
+		return nil, err
+	}
+	hold.BuyerID = input.BuyerID
+
+	if uc.rooms != nil {
+		claimed, err := claimQueueToken(ctx, uc.rooms, input.EventID, input.QueueToken, input.BuyerID)
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			hold.QueueToken = input.QueueToken
+			defer func() {
+				settleQueueToken(ctx, uc.rooms, input.EventID, input.QueueToken, err != nil)
+			}()
+		}
+	}
+
+	err = uc.repo.Transaction(ctx, func(ctx context.Context, repo domain.EventRepository) error {
+		if err := repo.CreateHold(ctx, hold); err != nil {
+			return err
+		}
+
+		for _, name := range hold.Spots {
+			spot, err := repo.FindSpotByName(ctx, event.ID, name)
+			if err != nil {
+				return err
+			}
+
+			if err := spot.Hold(hold.ID, hold.ExpiresAt); err != nil {
+				return err
+			}
+
+			if err := repo.HoldSpot(ctx, spot.ID, hold.ID, hold.ExpiresAt); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &HoldSpotsOutputDTO{
+		ID:        hold.ID,
+		EventID:   hold.EventID,
+		Spots:     hold.Spots,
+		ExpiresAt: hold.ExpiresAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
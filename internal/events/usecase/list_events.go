@@ -1,9 +1,40 @@
 package usecase
 
-import "github.com/devfullcycle/imersao18/golang/internal/events/domain"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/tracing"
+)
+
+const (
+	DefaultEventsPageSize = 20
+	MaxEventsPageSize     = 100
+)
+
+type ListEventsInputDTO struct {
+	// From and To bound the event date, as "2006-01-02" or "2006-01-02 15:04:05"
+	From         string
+	To           string
+	Location     string
+	Organization string
+	Rating       string
+	PartnerID    int
+	MinPrice     float64
+	MaxPrice     float64
+	Name         string
+	// Sort is date, price or name, a leading "-" sorts in descending order
+	Sort   string
+	Cursor string
+	Limit  int
+}
 
 type ListEventsOutputDTO struct {
-	Events []EventDTO `json:"events"`
+	Events     []EventDTO `json:"events"`
+	NextCursor string     `json:"next_cursor,omitempty"`
 }
 
 type ListEventsUseCase struct {
@@ -14,14 +45,22 @@ func NewListEventsUseCase(repo domain.EventRepository) *ListEventsUseCase {
 	return &ListEventsUseCase{repo: repo}
 }
 
-func (us *ListEventsUseCase) Execute() (*ListEventsOutputDTO, error) {
-	events, err := us.repo.ListEvents()
+func (us *ListEventsUseCase) Execute(ctx context.Context, input ListEventsInputDTO) (*ListEventsOutputDTO, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.ListEvents")
+	defer span.End()
+
+	filter, err := input.filter()
 	if err != nil {
 		return nil, err
 	}
 
-	eventsDTOs := make([]EventDTO, len(events))
-	for i, event := range events {
+	page, err := us.repo.ListEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	eventsDTOs := make([]EventDTO, len(page.Events))
+	for i, event := range page.Events {
 		eventsDTOs[i] = EventDTO{
 			ID:           event.ID,
 			Name:         event.Name,
@@ -36,5 +75,96 @@ func (us *ListEventsUseCase) Execute() (*ListEventsOutputDTO, error) {
 		}
 	}
 
-	return &ListEventsOutputDTO{Events: eventsDTOs}, nil
+	output := &ListEventsOutputDTO{Events: eventsDTOs}
+	if page.Next != nil {
+		output.NextCursor = page.Next.Encode()
+	}
+
+	return output, nil
+}
+
+func (input ListEventsInputDTO) filter() (domain.EventFilter, error) {
+	validation := &ValidationError{}
+	filter := domain.EventFilter{
+		Location:     input.Location,
+		Organization: input.Organization,
+		Rating:       domain.Rating(input.Rating),
+		PartnerID:    input.PartnerID,
+		MinPrice:     input.MinPrice,
+		MaxPrice:     input.MaxPrice,
+		Name:         input.Name,
+		Limit:        input.Limit,
+	}
+
+	if input.From != "" {
+		from, err := parseDateBound(input.From, false)
+		if err != nil {
+			validation.Add("from", err.Error())
+		}
+		filter.From = from
+	}
+	if input.To != "" {
+		to, err := parseDateBound(input.To, true)
+		if err != nil {
+			validation.Add("to", err.Error())
+		}
+		filter.To = to
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		validation.Add("to", "must not be before from")
+	}
+
+	if filter.MinPrice < 0 {
+		validation.Add("min_price", "must not be negative")
+	}
+	if filter.MaxPrice < 0 {
+		validation.Add("max_price", "must not be negative")
+	}
+	if filter.MaxPrice > 0 && filter.MaxPrice < filter.MinPrice {
+		validation.Add("max_price", "must not be lower than min_price")
+	}
+
+	sort, descending := strings.CutPrefix(input.Sort, "-")
+	filter.Sort = domain.EventSort(sort)
+	filter.Descending = descending
+	if filter.Sort == "" {
+		filter.Sort = domain.EventSortDate
+	}
+	if !domain.IsValidEventSort(filter.Sort) {
+		validation.Add("sort", fmt.Sprintf("must be one of %q, %q or %q", domain.EventSortDate, domain.EventSortPrice, domain.EventSortName))
+	}
+
+	if input.Cursor != "" {
+		cursor, err := domain.DecodeEventCursor(input.Cursor)
+		if err != nil || cursor.Sort != filter.Sort {
+			validation.Add("cursor", "is invalid for this sort")
+		}
+		filter.After = cursor
+	}
+
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = DefaultEventsPageSize
+	case filter.Limit < 0 || filter.Limit > MaxEventsPageSize:
+		validation.Add("limit", fmt.Sprintf("must be between 1 and %d", MaxEventsPageSize))
+	}
+
+	return filter, validation.Err()
+}
+
+// parseDateBound accepts a full timestamp or a bare date, a bare date used as
+// an upper bound covers the whole day
+func parseDateBound(value string, upper bool) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02 15:04:05", value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be formatted as 2006-01-02 or 2006-01-02 15:04:05")
+	}
+	if upper {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t, nil
 }
@@ -1,6 +1,11 @@
 package usecase
 
-import "github.com/devfullcycle/imersao18/golang/internal/events/domain"
+import (
+	"context"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/tracing"
+)
 
 type ListSpotsInputDTO struct {
 	EventID string `json:"event_id"`
@@ -19,13 +24,16 @@ func NewListSpotsUseCase(repo domain.EventRepository) *ListSpotsUseCase {
 	return &ListSpotsUseCase{repo: repo}
 }
 
-func (uc *ListSpotsUseCase) Execute(input ListSpotsInputDTO) (*ListSpotsOutputDTO, error) {
-	event, err := uc.repo.FindEventById(input.EventID)
+func (uc *ListSpotsUseCase) Execute(ctx context.Context, input ListSpotsInputDTO) (*ListSpotsOutputDTO, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.ListSpots")
+	defer span.End()
+
+	event, err := uc.repo.FindEventById(ctx, input.EventID)
 	if err != nil {
 		return nil, err
 	}
 
-	spots, err := uc.repo.FindSpotsByEventID(input.EventID)
+	spots, err := uc.repo.FindSpotsByEventID(ctx, input.EventID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/tracing"
+)
+
+type ReleaseExpiredHoldsOutputDTO struct {
+	Released int `json:"released"`
+}
+
+type ReleaseExpiredHoldsUseCase struct {
+	repo domain.EventRepository
+}
+
+func NewReleaseExpiredHoldsUseCase(repo domain.EventRepository) *ReleaseExpiredHoldsUseCase {
+	return &ReleaseExpiredHoldsUseCase{repo: repo}
+}
+
+func (uc *ReleaseExpiredHoldsUseCase) Execute(ctx context.Context) (*ReleaseExpiredHoldsOutputDTO, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.ReleaseExpiredHolds")
+	defer span.End()
+
+	released, err := uc.repo.ReleaseExpiredHolds(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReleaseExpiredHoldsOutputDTO{Released: released}, nil
+}
@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/service"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/tracing"
+)
+
+type UpdateEventInputDTO struct {
+	ID           string  `json:"-"`
+	Name         string  `json:"name"`
+	Location     string  `json:"location"`
+	Organization string  `json:"organization"`
+	Rating       string  `json:"rating"`
+	Date         string  `json:"date"`
+	ImageURL     string  `json:"image_url"`
+	Capacity     int     `json:"capacity"`
+	Price        float64 `json:"price"`
+	PartnerID    int     `json:"partner_id"`
+}
+
+type UpdateEventUseCase struct {
+	repo           domain.EventRepository
+	partnerFactory service.PartnerFactory
+}
+
+func NewUpdateEventUseCase(repo domain.EventRepository, partnerFactory service.PartnerFactory) *UpdateEventUseCase {
+	return &UpdateEventUseCase{repo: repo, partnerFactory: partnerFactory}
+}
+
+func (uc *UpdateEventUseCase) Execute(ctx context.Context, input UpdateEventInputDTO) (*EventDTO, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.UpdateEvent")
+	defer span.End()
+
+	event, err := uc.repo.FindEventById(ctx, input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	date, err := time.Parse("2006-01-02 15:04:05", input.Date)
+	if err != nil {
+		return nil, domain.ErrEventDateRequired
+	}
+
+	event.Name = input.Name
+	event.Location = input.Location
+	event.Organization = input.Organization
+	event.Rating = domain.Rating(input.Rating)
+	event.Date = date
+	event.ImageURL = input.ImageURL
+	event.Capacity = input.Capacity
+	event.Price = input.Price
+	event.PartnerID = input.PartnerID
+
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := checkPartner(uc.partnerFactory, event.PartnerID); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.UpdateEvent(ctx, event); err != nil {
+		return nil, err
+	}
+
+	return &EventDTO{
+		ID:           event.ID,
+		Name:         event.Name,
+		Location:     event.Location,
+		Organization: event.Organization,
+		Rating:       string(event.Rating),
+		Date:         event.Date.Format("2006-01-02 15:04:05"),
+		ImageURL:     event.ImageURL,
+		Capacity:     event.Capacity,
+		Price:        event.Price,
+		PartnerID:    event.PartnerID,
+	}, nil
+}
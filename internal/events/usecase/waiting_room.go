@@ -0,0 +1,345 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain"
+	"github.com/devfullcycle/imersao18/golang/internal/events/domain/infra/tracing"
+)
+
+// Actions of ControlWaitingRoomUseCase
+const (
+	WaitingRoomActionOpen  = "open"
+	WaitingRoomActionPause = "pause"
+	WaitingRoomActionDrain = "drain"
+	WaitingRoomActionClose = "close"
+)
+
+type WaitingRoomDTO struct {
+	EventID             string `json:"event_id"`
+	Status              string `json:"status"`
+	AdmissionsPerMinute int    `json:"admissions_per_minute"`
+	AdmissionWindow     string `json:"admission_window"`
+	Waiting             int    `json:"waiting"`
+	Admitted            int    `json:"admitted"`
+}
+
+type QueueTokenDTO struct {
+	Token    string `json:"token"`
+	EventID  string `json:"event_id"`
+	Status   string `json:"status"`
+	Position int    `json:"position"`
+	// EstimatedWaitSeconds is 0 once admitted or while the room is paused
+	EstimatedWaitSeconds int    `json:"estimated_wait_seconds"`
+	ExpiresAt            string `json:"expires_at,omitempty"`
+}
+
+func newWaitingRoomDTO(room *domain.WaitingRoom) *WaitingRoomDTO {
+	return &WaitingRoomDTO{
+		EventID:             room.EventID,
+		Status:              string(room.Status),
+		AdmissionsPerMinute: room.AdmissionsPerMinute,
+		AdmissionWindow:     room.AdmissionWindow.String(),
+		Waiting:             room.Waiting(),
+		Admitted:            room.Admitted(),
+	}
+}
+
+func newQueueTokenDTO(room *domain.WaitingRoom, token *domain.QueueToken) *QueueTokenDTO {
+	dto := &QueueTokenDTO{
+		Token:                token.Token,
+		EventID:              token.EventID,
+		Status:               string(token.Status),
+		Position:             token.Position,
+		EstimatedWaitSeconds: int(room.EstimatedWait(token.Position).Seconds()),
+	}
+	if !token.ExpiresAt.IsZero() {
+		dto.ExpiresAt = token.ExpiresAt.Format("2006-01-02 15:04:05")
+	}
+	return dto
+}
+
+// advanceRoom brings a room up to now, and removes it once it is drained so
+// the event goes back to selling without a queue
+func advanceRoom(room *domain.WaitingRoom, now time.Time) *domain.WaitingRoom {
+	if room == nil {
+		return nil
+	}
+	room.Advance(now)
+	if room.Drained() {
+		return nil
+	}
+	return room
+}
+
+type GetWaitingRoomInputDTO struct {
+	EventID string
+}
+
+type GetWaitingRoomUseCase struct {
+	rooms domain.WaitingRoomRepository
+}
+
+func NewGetWaitingRoomUseCase(rooms domain.WaitingRoomRepository) *GetWaitingRoomUseCase {
+	return &GetWaitingRoomUseCase{rooms: rooms}
+}
+
+func (uc *GetWaitingRoomUseCase) Execute(ctx context.Context, input GetWaitingRoomInputDTO) (*WaitingRoomDTO, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.GetWaitingRoom")
+	defer span.End()
+
+	var output *WaitingRoomDTO
+	err := uc.rooms.Update(ctx, input.EventID, func(room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+		room = advanceRoom(room, time.Now())
+		if room == nil {
+			return nil, domain.ErrWaitingRoomNotFound
+		}
+		output = newWaitingRoomDTO(room)
+		return room, nil
+	})
+	return output, err
+}
+
+type ControlWaitingRoomInputDTO struct {
+	EventID string `json:"-"`
+	Action  string `json:"-"`
+	// AdmissionsPerMinute and AdmissionWindow apply to the open action, zero
+	// values keep the current settings or the defaults
+	AdmissionsPerMinute int    `json:"admissions_per_minute"`
+	AdmissionWindow     string `json:"admission_window"`
+}
+
+// ControlWaitingRoomUseCase opens, pauses, drains and closes the waiting room of an event
+type ControlWaitingRoomUseCase struct {
+	eventRepo                  domain.EventRepository
+	rooms                      domain.WaitingRoomRepository
+	defaultAdmissionsPerMinute int
+	defaultAdmissionWindow     time.Duration
+}
+
+func NewControlWaitingRoomUseCase(eventRepo domain.EventRepository, rooms domain.WaitingRoomRepository, defaultAdmissionsPerMinute int, defaultAdmissionWindow time.Duration) *ControlWaitingRoomUseCase {
+	return &ControlWaitingRoomUseCase{
+		eventRepo:                  eventRepo,
+		rooms:                      rooms,
+		defaultAdmissionsPerMinute: defaultAdmissionsPerMinute,
+		defaultAdmissionWindow:     defaultAdmissionWindow,
+	}
+}
+
+// Execute returns no room when it was closed
+func (uc *ControlWaitingRoomUseCase) Execute(ctx context.Context, input ControlWaitingRoomInputDTO) (*WaitingRoomDTO, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.ControlWaitingRoom")
+	defer span.End()
+
+	var window time.Duration
+	if input.AdmissionWindow != "" {
+		var err error
+		if window, err = time.ParseDuration(input.AdmissionWindow); err != nil {
+			validation := &ValidationError{}
+			validation.Add("admission_window", "must be a duration like 5m")
+			return nil, validation
+		}
+	}
+
+	if _, err := uc.eventRepo.FindEventById(ctx, input.EventID); err != nil {
+		return nil, err
+	}
+
+	var output *WaitingRoomDTO
+	err := uc.rooms.Update(ctx, input.EventID, func(room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+		now := time.Now()
+		room = advanceRoom(room, now)
+
+		switch input.Action {
+		case WaitingRoomActionOpen:
+			rate, admissionWindow := uc.defaultAdmissionsPerMinute, uc.defaultAdmissionWindow
+			if room != nil {
+				rate, admissionWindow = room.AdmissionsPerMinute, room.AdmissionWindow
+			}
+			if input.AdmissionsPerMinute != 0 {
+				rate = input.AdmissionsPerMinute
+			}
+			if window != 0 {
+				admissionWindow = window
+			}
+
+			var err error
+			if room == nil {
+				room, err = domain.NewWaitingRoom(input.EventID, rate, admissionWindow, now)
+			} else {
+				err = room.Open(rate, admissionWindow, now)
+			}
+			if err != nil {
+				return nil, err
+			}
+		case WaitingRoomActionPause, WaitingRoomActionDrain:
+			if room == nil {
+				return nil, domain.ErrWaitingRoomNotFound
+			}
+			if input.Action == WaitingRoomActionPause {
+				room.Pause(now)
+			} else {
+				room.Drain(now)
+			}
+		case WaitingRoomActionClose:
+			if room == nil {
+				return nil, domain.ErrWaitingRoomNotFound
+			}
+			return nil, nil
+		default:
+			return nil, domain.ErrInvalidWaitingRoomAction
+		}
+
+		output = newWaitingRoomDTO(room)
+		return advanceRoom(room, now), nil
+	})
+	return output, err
+}
+
+type JoinWaitingRoomInputDTO struct {
+	EventID string
+	// BuyerID is the authenticated buyer, only they can use the token
+	BuyerID string
+}
+
+type JoinWaitingRoomUseCase struct {
+	rooms domain.WaitingRoomRepository
+}
+
+func NewJoinWaitingRoomUseCase(rooms domain.WaitingRoomRepository) *JoinWaitingRoomUseCase {
+	return &JoinWaitingRoomUseCase{rooms: rooms}
+}
+
+func (uc *JoinWaitingRoomUseCase) Execute(ctx context.Context, input JoinWaitingRoomInputDTO) (*QueueTokenDTO, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.JoinWaitingRoom")
+	defer span.End()
+
+	var output *QueueTokenDTO
+	err := uc.rooms.Update(ctx, input.EventID, func(room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+		now := time.Now()
+		room = advanceRoom(room, now)
+		if room == nil {
+			return nil, domain.ErrWaitingRoomNotFound
+		}
+
+		token, err := room.Join(input.BuyerID, now)
+		if err != nil {
+			return room, err
+		}
+		output = newQueueTokenDTO(room, token)
+		return room, nil
+	})
+	return output, err
+}
+
+type GetQueueTokenInputDTO struct {
+	EventID string
+	Token   string
+	BuyerID string
+}
+
+type GetQueueTokenUseCase struct {
+	rooms domain.WaitingRoomRepository
+}
+
+func NewGetQueueTokenUseCase(rooms domain.WaitingRoomRepository) *GetQueueTokenUseCase {
+	return &GetQueueTokenUseCase{rooms: rooms}
+}
+
+func (uc *GetQueueTokenUseCase) Execute(ctx context.Context, input GetQueueTokenInputDTO) (*QueueTokenDTO, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "usecase.GetQueueToken")
+	defer span.End()
+
+	var output *QueueTokenDTO
+	err := uc.rooms.Update(ctx, input.EventID, func(room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+		room = advanceRoom(room, time.Now())
+		if room == nil {
+			return nil, domain.ErrQueueTokenNotFound
+		}
+
+		token, err := room.Token(input.Token, input.BuyerID)
+		if err != nil {
+			return room, err
+		}
+		output = newQueueTokenDTO(room, token)
+		return room, nil
+	})
+	return output, err
+}
+
+// claimQueueToken reserves the admitted queue token of a buyer for a checkout
+// or a hold, it claims nothing when the event has no waiting room
+func claimQueueToken(ctx context.Context, rooms domain.WaitingRoomRepository, eventID, token, buyerID string) (bool, error) {
+	claimed := false
+	err := rooms.Update(ctx, eventID, func(room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+		room = advanceRoom(room, time.Now())
+		if room == nil {
+			return nil, nil
+		}
+		if token == "" {
+			return room, domain.ErrQueueTokenRequired
+		}
+		if err := room.Claim(token, buyerID); err != nil {
+			return room, err
+		}
+		claimed = true
+		return room, nil
+	})
+	return claimed, err
+}
+
+// settleQueueToken uses up a claimed token once what it was claimed for
+// succeeded, and gives it back when it failed
+func settleQueueToken(ctx context.Context, rooms domain.WaitingRoomRepository, eventID, token string, failed bool) {
+	// The checkout may have outlived the request, settle the token anyway
+	ctx = context.WithoutCancel(ctx)
+	rooms.Update(ctx, eventID, func(room *domain.WaitingRoom) (*domain.WaitingRoom, error) {
+		if room == nil {
+			return nil, nil
+		}
+		if failed {
+			room.Release(token, time.Now())
+		} else {
+			room.Consume(token, time.Now())
+		}
+		return advanceRoom(room, time.Now()), nil
+	})
+}
+
+type waitingRoomBuyTickets struct {
+	next  BuyTickets
+	rooms domain.WaitingRoomRepository
+	holds domain.EventRepository
+}
+
+// NewWaitingRoomBuyTickets puts the waiting rooms in front of next: checkouts
+// of an event with a waiting room need an admitted queue token, used up by a
+// successful checkout. The checkout of a hold placed with a queue token by
+// the same buyer needs none, the hold already used one up.
+func NewWaitingRoomBuyTickets(next BuyTickets, rooms domain.WaitingRoomRepository, holds domain.EventRepository) BuyTickets {
+	return &waitingRoomBuyTickets{next: next, rooms: rooms, holds: holds}
+}
+
+func (uc *waitingRoomBuyTickets) Execute(ctx context.Context, input BuyTicketInputDTO) (*BuyTicketsOutputDTO, error) {
+	if input.HoldID != "" {
+		hold, err := uc.holds.FindHoldByID(ctx, input.HoldID)
+		if err != nil {
+			return nil, err
+		}
+		if hold.QueueToken != "" && hold.EventID == input.EventID && hold.BuyerID == input.BuyerID {
+			return uc.next.Execute(ctx, input)
+		}
+	}
+
+	claimed, err := claimQueueToken(ctx, uc.rooms, input.EventID, input.QueueToken, input.BuyerID)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := uc.next.Execute(ctx, input)
+	if claimed {
+		settleQueueToken(ctx, uc.rooms, input.EventID, input.QueueToken, err != nil)
+	}
+	return output, err
+}
@@ -0,0 +1,65 @@
+package fakepartner
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// The /_fake endpoints script a running fake partner, e.g.
+//
+//	curl -X PUT localhost:3333/_fake/failures -d '{"status_code": 503, "times": 2}'
+//	curl -X PUT localhost:3334/_fake/events/1/sold -d '["A1", "A2"]'
+
+type failuresInput struct {
+	Latency       string `json:"latency"`
+	StatusCode    int    `json:"status_code"`
+	MalformedJSON bool   `json:"malformed_json"`
+	Times         int    `json:"times"`
+}
+
+func (s *Server) putFailures(w http.ResponseWriter, r *http.Request) {
+	var input failuresInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	failures := Failures{
+		StatusCode:    input.StatusCode,
+		MalformedJSON: input.MalformedJSON,
+		Times:         input.Times,
+	}
+	if input.Latency != "" {
+		latency, err := time.ParseDuration(input.Latency)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		failures.Latency = latency
+	}
+
+	s.SetFailures(failures)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) putSold(w http.ResponseWriter, r *http.Request) {
+	var spots []string
+	if err := json.NewDecoder(r.Body).Decode(&spots); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.MarkSold(r.PathValue("eventID"), spots...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listReservations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Reservations(r.PathValue("eventID")))
+}
+
+func (s *Server) reset(w http.ResponseWriter, r *http.Request) {
+	s.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
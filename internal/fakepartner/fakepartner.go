@@ -0,0 +1,256 @@
+// Package fakepartner emulates the ticketing partners for local development
+// and tests. A Server speaks the Partner1 or Partner2 protocol, keeps the sold
+// seats in memory and can be told to misbehave.
+package fakepartner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Failures makes a Server misbehave on reservation requests
+type Failures struct {
+	// Latency delays every reservation answer
+	Latency time.Duration
+	// StatusCode answers reservations with this HTTP status instead of reserving, e.g. 503
+	StatusCode int
+	// MalformedJSON answers reservations with a broken JSON body
+	MalformedJSON bool
+	// Times limits StatusCode and MalformedJSON to the next Times reservations, 0 for all of them
+	Times int
+}
+
+// Reservation is a seat sold by the fake partner
+type Reservation struct {
+	ID         string `json:"id"`
+	EventID    string `json:"event_id"`
+	Spot       string `json:"spot"`
+	TicketType string `json:"ticket_type"`
+	Email      string `json:"email"`
+}
+
+type Server struct {
+	protocol Protocol
+	// token is the expected bearer token, empty accepts every request
+	token string
+	mux   *http.ServeMux
+
+	mu       sync.Mutex
+	sold     map[string]map[string]Reservation
+	replies  map[string][]byte
+	failures Failures
+	failed   int
+	nextID   int
+}
+
+// NewServer creates a fake partner speaking protocol. Requests must carry
+// token as a bearer token unless it is empty.
+func NewServer(protocol Protocol, token string) *Server {
+	s := &Server{
+		protocol: protocol,
+		token:    token,
+		mux:      http.NewServeMux(),
+	}
+	s.Reset()
+
+	s.mux.HandleFunc("POST "+protocol.reservePath(), s.reserve)
+	s.mux.HandleFunc("POST "+protocol.cancelPath(), s.cancel)
+	s.mux.HandleFunc("GET /_fake/events/{eventID}/reservations", s.listReservations)
+	s.mux.HandleFunc("PUT /_fake/failures", s.putFailures)
+	s.mux.HandleFunc("PUT /_fake/events/{eventID}/sold", s.putSold)
+	s.mux.HandleFunc("POST /_fake/reset", s.reset)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// SetFailures replaces the failure script
+func (s *Server) SetFailures(failures Failures) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = failures
+	s.failed = 0
+}
+
+// MarkSold makes spots of an event already sold, as if another channel sold them
+func (s *Server) MarkSold(eventID string, spots ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, spot := range spots {
+		s.sellLocked(eventID, spot, "", "")
+	}
+}
+
+// Reservations returns the seats sold on an event, by spot name
+func (s *Server) Reservations(eventID string) []Reservation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reservations := make([]Reservation, 0, len(s.sold[eventID]))
+	for _, reservation := range s.sold[eventID] {
+		reservations = append(reservations, reservation)
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		return reservations[i].Spot < reservations[j].Spot
+	})
+	return reservations
+}
+
+// Reset forgets every reservation and failure
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sold = map[string]map[string]Reservation{}
+	s.replies = map[string][]byte{}
+	s.failures = Failures{}
+	s.failed = 0
+}
+
+func (s *Server) reserve(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	request, err := s.protocol.decodeReserve(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	request.EventID = r.PathValue("eventID")
+
+	failures, fail := s.nextFailure()
+	if failures.Latency > 0 {
+		select {
+		case <-time.After(failures.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if fail && failures.StatusCode != 0 {
+		http.Error(w, http.StatusText(failures.StatusCode), failures.StatusCode)
+		return
+	}
+	if fail && failures.MalformedJSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"id": "broken", `))
+		return
+	}
+
+	key := r.Header.Get("Idempotency-Key")
+	body, err := s.reserveSpots(request, key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// reserveSpots sells the free spots of the request and reports the sold ones
+// as rejected. A reservation retried with the same idempotency key gets the
+// first answer again.
+func (s *Server) reserveSpots(request reserveRequest, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if body, ok := s.replies[key]; ok && key != "" {
+		return body, nil
+	}
+
+	results := make([]reserveResult, len(request.Spots))
+	for i, spot := range request.Spots {
+		results[i] = reserveResult{Spot: spot}
+		if _, sold := s.sold[request.EventID][spot]; sold {
+			results[i].Reason = "spot already sold"
+			continue
+		}
+
+		results[i].Reservation = s.sellLocked(request.EventID, spot, request.TicketType, request.Email)
+		results[i].Confirmed = true
+	}
+
+	body, err := json.Marshal(s.protocol.encodeReserve(request, results))
+	if err != nil {
+		return nil, err
+	}
+
+	if key != "" {
+		s.replies[key] = body
+	}
+	return body, nil
+}
+
+func (s *Server) cancel(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	request, err := s.protocol.decodeCancel(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	eventID := r.PathValue("eventID")
+	canceled := make(map[string]bool, len(request.ReservationIDs))
+	for _, id := range request.ReservationIDs {
+		canceled[id] = true
+	}
+	for spot, reservation := range s.sold[eventID] {
+		if canceled[reservation.ID] {
+			delete(s.sold[eventID], spot)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	return s.token == "" || r.Header.Get("Authorization") == "Bearer "+s.token
+}
+
+// nextFailure returns the failure script and whether the current request must fail
+func (s *Server) nextFailure() (Failures, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failures.Times > 0 && s.failed >= s.failures.Times {
+		return Failures{Latency: s.failures.Latency}, false
+	}
+	s.failed++
+	return s.failures, true
+}
+
+func (s *Server) sellLocked(eventID, spot, ticketType, email string) Reservation {
+	s.nextID++
+	reservation := Reservation{
+		ID:         fmt.Sprintf("%s-%d", s.protocol, s.nextID),
+		EventID:    eventID,
+		Spot:       spot,
+		TicketType: ticketType,
+		Email:      email,
+	}
+
+	if s.sold[eventID] == nil {
+		s.sold[eventID] = map[string]Reservation{}
+	}
+	s.sold[eventID][spot] = reservation
+	return reservation
+}
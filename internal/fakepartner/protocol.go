@@ -0,0 +1,166 @@
+package fakepartner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Protocol is the HTTP API a fake partner speaks
+type Protocol string
+
+const (
+	// Partner1Protocol serves POST /events/{id}/reserve and /events/{id}/cancel in English
+	Partner1Protocol Protocol = "partner1"
+	// Partner2Protocol serves POST /eventos/{id}/reservar and /eventos/{id}/cancelar in Portuguese
+	Partner2Protocol Protocol = "partner2"
+)
+
+func IsValidProtocol(protocol Protocol) bool {
+	return protocol == Partner1Protocol || protocol == Partner2Protocol
+}
+
+type reserveRequest struct {
+	EventID    string
+	Spots      []string
+	TicketType string
+	Email      string
+}
+
+type cancelRequest struct {
+	ReservationIDs []string
+}
+
+// reserveResult is the outcome of one requested spot
+type reserveResult struct {
+	Spot        string
+	Confirmed   bool
+	Reason      string
+	Reservation Reservation
+}
+
+type partner1ReserveRequest struct {
+	Spots      []string `json:"spots"`
+	TicketKind string   `json:"ticket_kind"`
+	Email      string   `json:"email"`
+}
+
+type partner1Reservation struct {
+	ID         string `json:"id"`
+	Email      string `json:"email"`
+	Spot       string `json:"spot"`
+	TicketKind string `json:"ticket_kind"`
+	Status     string `json:"status"`
+	EventID    string `json:"event_id"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+type partner1CancelRequest struct {
+	Reservations []string `json:"reservations"`
+}
+
+type partner2ReserveRequest struct {
+	Lugares      []string `json:"lugares"`
+	TipoIngresso string   `json:"tipo_ingresso"`
+	Email        string   `json:"email"`
+}
+
+type partner2Reservation struct {
+	ID           string `json:"id"`
+	Email        string `json:"email"`
+	Lugar        string `json:"lugar"`
+	TipoIngresso string `json:"tipo_ingresso"`
+	Estado       string `json:"Estado"`
+	EventoID     string `json:"evento_id"`
+	Motivo       string `json:"motivo,omitempty"`
+}
+
+type partner2CancelRequest struct {
+	Reservas []string `json:"reservas"`
+}
+
+func (p Protocol) reservePath() string {
+	if p == Partner2Protocol {
+		return "/eventos/{eventID}/reservar"
+	}
+	return "/events/{eventID}/reserve"
+}
+
+func (p Protocol) cancelPath() string {
+	if p == Partner2Protocol {
+		return "/eventos/{eventID}/cancelar"
+	}
+	return "/events/{eventID}/cancel"
+}
+
+func (p Protocol) decodeReserve(r *http.Request) (reserveRequest, error) {
+	if p == Partner2Protocol {
+		var body partner2ReserveRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return reserveRequest{}, err
+		}
+		if len(body.Lugares) == 0 {
+			return reserveRequest{}, fmt.Errorf("lugares is required")
+		}
+		return reserveRequest{Spots: body.Lugares, TicketType: body.TipoIngresso, Email: body.Email}, nil
+	}
+
+	var body partner1ReserveRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return reserveRequest{}, err
+	}
+	if len(body.Spots) == 0 {
+		return reserveRequest{}, fmt.Errorf("spots is required")
+	}
+	return reserveRequest{Spots: body.Spots, TicketType: body.TicketKind, Email: body.Email}, nil
+}
+
+func (p Protocol) decodeCancel(r *http.Request) (cancelRequest, error) {
+	if p == Partner2Protocol {
+		var body partner2CancelRequest
+		err := json.NewDecoder(r.Body).Decode(&body)
+		return cancelRequest{ReservationIDs: body.Reservas}, err
+	}
+
+	var body partner1CancelRequest
+	err := json.NewDecoder(r.Body).Decode(&body)
+	return cancelRequest{ReservationIDs: body.Reservations}, err
+}
+
+func (p Protocol) encodeReserve(request reserveRequest, results []reserveResult) any {
+	if p == Partner2Protocol {
+		items := make([]partner2Reservation, len(results))
+		for i, result := range results {
+			items[i] = partner2Reservation{
+				ID:           result.Reservation.ID,
+				Email:        request.Email,
+				Lugar:        result.Spot,
+				TipoIngresso: request.TicketType,
+				Estado:       "reservado",
+				EventoID:     request.EventID,
+			}
+			if !result.Confirmed {
+				items[i].Estado = "recusado"
+				items[i].Motivo = result.Reason
+			}
+		}
+		return items
+	}
+
+	items := make([]partner1Reservation, len(results))
+	for i, result := range results {
+		items[i] = partner1Reservation{
+			ID:         result.Reservation.ID,
+			Email:      request.Email,
+			Spot:       result.Spot,
+			TicketKind: request.TicketType,
+			Status:     "reserved",
+			EventID:    request.EventID,
+		}
+		if !result.Confirmed {
+			items[i].Status = "rejected"
+			items[i].Reason = result.Reason
+		}
+	}
+	return items
+}
@@ -0,0 +1,19 @@
+package fakepartner
+
+import "net/http/httptest"
+
+// TestServer is a fake partner listening on a local port, for tests that go
+// through the real partner adapters
+type TestServer struct {
+	*httptest.Server
+	Partner *Server
+}
+
+// NewTestServer starts a fake partner speaking protocol, the caller must Close it
+func NewTestServer(protocol Protocol, token string) *TestServer {
+	partner := NewServer(protocol, token)
+	return &TestServer{
+		Server:  httptest.NewServer(partner),
+		Partner: partner,
+	}
+}
@@ -0,0 +1,117 @@
+// Package logging configures the structured logger and carries the request
+// ID from the HTTP layer down to the partner calls.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID in and out of the service
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID of the request ctx belongs to, empty outside of one
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// New creates a logger writing text or json records. Records logged with a
+// request context get its request_id and trace_id, and email and card_hash attributes are
+// redacted in case a caller forgot to.
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	switch format {
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", format)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+// contextHandler adds the request and trace IDs of the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	switch attr.Key {
+	case "email":
+		return Email(attr.Value.String())
+	case "card_hash":
+		return CardHash(attr.Value.String())
+	}
+	return attr
+}
+
+// Email logs an email address keeping only its first letter and domain
+func Email(email string) slog.Attr {
+	return slog.String("email", RedactEmail(email))
+}
+
+// CardHash logs the last four characters of a card hash
+func CardHash(hash string) slog.Attr {
+	return slog.String("card_hash", RedactCardHash(hash))
+}
+
+// RedactEmail turns john@example.com into j***@example.com
+func RedactEmail(email string) string {
+	if email == "" {
+		return ""
+	}
+
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	return local[:1] + "***@" + domain
+}
+
+// RedactCardHash turns a card hash into ****abcd, hiding short ones completely
+func RedactCardHash(hash string) string {
+	if hash == "" || strings.HasPrefix(hash, "****") {
+		return hash
+	}
+	if len(hash) <= 8 {
+		return "****"
+	}
+	return "****" + hash[len(hash)-4:]
+}